@@ -0,0 +1,114 @@
+package coreapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/intercom/gocore/log"
+)
+
+// LogLevelHandler is an admin http.Handler for reading and changing a log level at runtime.
+//
+// GET returns the current level. PUT takes a JSON body such as {"level": "debug", "timeout": "10m"};
+// if a timeout is given, the level reverts to its previous value once it elapses.
+// As it changes logging for the whole service, mount it behind some authentication, e.g. WithBasicAuth.
+type LogLevelHandler struct {
+	level *log.AtomicLevel
+
+	mu       sync.Mutex
+	revert   *time.Timer
+	revertAt time.Time
+	revertTo log.Level
+}
+
+func NewLogLevelHandler(level *log.AtomicLevel) *LogLevelHandler {
+	return &LogLevelHandler{level: level}
+}
+
+type logLevelRequest struct {
+	Level   string `json:"level"`
+	Timeout string `json:"timeout"`
+}
+
+type logLevelResponse struct {
+	Level    string     `json:"level"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+func (h *LogLevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.response().WriteTo(w)
+	case http.MethodPut:
+		req := logLevelRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			JSONErrorResponse(http.StatusBadRequest, errors.New("Invalid JSON body")).WriteTo(w)
+			return
+		}
+		level, err := log.ParseLevel(req.Level)
+		if err != nil {
+			JSONErrorResponse(http.StatusBadRequest, err).WriteTo(w)
+			return
+		}
+		var timeout time.Duration
+		if req.Timeout != "" {
+			if timeout, err = time.ParseDuration(req.Timeout); err != nil || timeout <= 0 {
+				JSONErrorResponse(http.StatusBadRequest, errors.New("Invalid timeout")).WriteTo(w)
+				return
+			}
+		}
+		h.setLevel(level, timeout)
+		h.response().WriteTo(w)
+	default:
+		JSONErrorResponse(http.StatusMethodNotAllowed, errors.New("Method Not Allowed")).WriteTo(w)
+	}
+}
+
+// setLevel changes the level, cancelling any pending revert. A positive timeout schedules a revert
+// to the level in place before any temporary change.
+func (h *LogLevelHandler) setLevel(level log.Level, timeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	previous := h.level.Level()
+	if h.revert != nil {
+		previous = h.revertTo
+		h.revert.Stop()
+		h.revert = nil
+		h.revertAt = time.Time{}
+	}
+	h.level.SetLevel(level)
+	if timeout <= 0 {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(timeout, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.revert != timer {
+			return // superseded by a later change
+		}
+		h.level.SetLevel(previous)
+		h.revert = nil
+		h.revertAt = time.Time{}
+	})
+	h.revert = timer
+	h.revertAt = time.Now().Add(timeout).UTC()
+	h.revertTo = previous
+}
+
+func (h *LogLevelHandler) response() *Response {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	resp := logLevelResponse{Level: h.level.Level().String()}
+	if h.revert != nil {
+		revertAt := h.revertAt
+		resp.RevertAt = &revertAt
+	}
+	return JSONResponse(http.StatusOK, resp)
+}
@@ -0,0 +1,71 @@
+package coreapi_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intercom/gocore/coreapi"
+	"github.com/intercom/gocore/log"
+)
+
+func TestLogLevelHandlerGet(t *testing.T) {
+	logger := log.LogfmtLoggerTo(&bytes.Buffer{})
+	handler := coreapi.NewLogLevelHandler(logger.AtomicLevel())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/log_level", nil))
+	if want, have := `{"level":"info"}`, w.Body.String(); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestLogLevelHandlerPutChangesDerivedLoggers(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := log.LogfmtLoggerTo(&buf)
+	derived := logger.With("foo", "bar")
+	handler := coreapi.NewLogLevelHandler(logger.AtomicLevel())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("PUT", "/log_level", strings.NewReader(`{"level":"debug"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status 200, have %d", w.Code)
+	}
+
+	derived.LogDebugMessage("hello")
+	if !strings.Contains(buf.String(), "level=debug") {
+		t.Errorf("derived logger did not log at debug, have %s", buf.String())
+	}
+}
+
+func TestLogLevelHandlerPutWithTimeoutReverts(t *testing.T) {
+	level := log.NewAtomicLevel(log.InfoLevel)
+	handler := coreapi.NewLogLevelHandler(level)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("PUT", "/log_level", strings.NewReader(`{"level":"debug","timeout":"20ms"}`)))
+	if !strings.Contains(w.Body.String(), "revert_at") {
+		t.Errorf("expected revert_at in response, have %s", w.Body.String())
+	}
+	if level.Level() != log.DebugLevel {
+		t.Errorf("want %v, have %v", log.DebugLevel, level.Level())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if level.Level() != log.InfoLevel {
+		t.Errorf("want reverted to %v, have %v", log.InfoLevel, level.Level())
+	}
+}
+
+func TestLogLevelHandlerPutInvalidLevel(t *testing.T) {
+	handler := coreapi.NewLogLevelHandler(log.NewAtomicLevel(log.InfoLevel))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("PUT", "/log_level", strings.NewReader(`{"level":"loud"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want status 400, have %d", w.Code)
+	}
+}
@@ -62,7 +62,7 @@ func WithRequestID(next http.Handler) http.Handler {
 	})
 }
 
-// WithLogger adds a "logger" key to the request context.
+// WithLogger adds a logger to the request context, retrievable with GetLogger or log.FromContext.
 // it will use a requestID as a standard field, if available
 func WithLogger(base log.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -72,7 +72,7 @@ func WithLogger(base log.Logger) func(next http.Handler) http.Handler {
 			if requestID != nil {
 				annotatedLog = annotatedLog.With("requestID", requestID)
 			}
-			r = r.WithContext(context.WithValue(r.Context(), "logger", annotatedLog))
+			r = r.WithContext(log.NewContext(r.Context(), annotatedLog))
 			next.ServeHTTP(w, r)
 		}
 		return http.HandlerFunc(fn)
@@ -112,7 +112,7 @@ func GetRequestID(r *http.Request) string {
 
 // Get the Logger stored in request context, or nil.
 func GetLogger(r *http.Request) log.Logger {
-	logger, ok := r.Context().Value("logger").(log.Logger)
+	logger, ok := log.ContextLogger(r.Context())
 	if !ok {
 		return nil
 	}
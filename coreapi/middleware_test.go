@@ -51,6 +51,24 @@ func TestWithLogger(t *testing.T) {
 	f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/foo", nil))
 }
 
+func TestWithLoggerFromContext(t *testing.T) {
+	logger := log.JSONLoggerTo(os.Stderr)
+	next := func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := log.ContextLogger(r.Context()); !ok {
+			t.Errorf("did not find a logger in the request context")
+		}
+	}
+
+	f := coreapi.WithLogger(logger)(http.HandlerFunc(next))
+	f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/foo", nil))
+}
+
+func TestGetLoggerWithoutLogger(t *testing.T) {
+	if coreapi.GetLogger(httptest.NewRequest("GET", "/foo", nil)) != nil {
+		t.Errorf("expected no logger")
+	}
+}
+
 func TestWithLoggerAndRequestID(t *testing.T) {
 	logger := log.JSONLoggerTo(os.Stderr)
 	next := func(w http.ResponseWriter, r *http.Request) {
@@ -2,16 +2,45 @@ package log
 
 import (
 	"io"
+	stdlog "log"
 	"regexp"
+	"strings"
 	"time"
 )
 
+// NewWriterToLog returns a writer logging each line written to it, at ErrorLevel unless the line has a level prefix.
 func NewWriterToLog(log Logger) io.Writer {
-	return &StdlibAdapter{Logger: log}
+	return NewStdlibAdapter(log, ErrorLevel)
 }
 
+// NewStdlibAdapter returns a StdlibAdapter logging lines without a level prefix at defaultLevel.
+func NewStdlibAdapter(log Logger, defaultLevel Level) *StdlibAdapter {
+	return &StdlibAdapter{Logger: log, DefaultLevel: &defaultLevel}
+}
+
+// RedirectStdlibLog sends output from the standard library log package to logger, with the file and line
+// logged from as "caller". It returns a *log.Logger doing the same, for loggers such as http.Server.ErrorLog
+// that aren't the standard logger; a nil http.Server.ErrorLog already uses the standard logger.
+// It replaces the standard logger's output, clears its prefix and sets its flags to log.Lshortfile;
+// save them first with log.Writer, log.Prefix and log.Flags to restore them later.
+func RedirectStdlibLog(logger Logger, defaultLevel Level) *stdlog.Logger {
+	adapter := NewStdlibAdapter(logger, defaultLevel)
+	stdlog.SetOutput(adapter)
+	stdlog.SetPrefix("")
+	stdlog.SetFlags(stdlog.Lshortfile)
+	return stdlog.New(adapter, "", stdlog.Lshortfile)
+}
+
+// StdlibAdapter is an io.Writer for the standard library log package, parsing the date, time, file and
+// message out of each line and logging them to Logger.
+// Messages starting with a level, as in "[WARN] ...", "INFO: ..." or containing "level=debug", are logged
+// at that level with it removed; others are logged at DefaultLevel.
 type StdlibAdapter struct {
 	Logger Logger
+	// DefaultLevel is the level of lines without one; if nil, ErrorLevel.
+	DefaultLevel *Level
+	// Prefix is the prefix set on the standard library logger, removed from lines before parsing.
+	Prefix string
 }
 
 func (a *StdlibAdapter) Write(p []byte) (int, error) {
@@ -31,25 +60,91 @@ func (a *StdlibAdapter) Write(p []byte) (int, error) {
 		t, _ := time.Parse("2006/01/02 15:04:05", timestamp)
 		keyvals = append(keyvals, "timestamp", t)
 	}
+	if file, ok := result["file"]; ok && file != "" {
+		keyvals = append(keyvals, "caller", file)
+	}
+	level := a.defaultLevel()
 	if msg, ok := result["msg"]; ok {
+		msg = strings.TrimPrefix(msg, a.Prefix) // with log.Lmsgprefix
+		level, msg = a.levelFrom(msg)
 		keyvals = append(keyvals, "msg", msg)
 	}
-	a.Logger.LogError(keyvals...)
+
+	switch level {
+	case DebugLevel:
+		a.Logger.LogDebug(keyvals...)
+	case InfoLevel:
+		a.Logger.LogInfo(keyvals...)
+	case WarnLevel:
+		a.Logger.LogWarn(keyvals...)
+	default:
+		a.Logger.LogError(keyvals...)
+	}
 	return len(p), nil
 }
 
+// levelFrom returns the level named by a prefix or level= field in msg, and msg without it.
+func (a *StdlibAdapter) levelFrom(msg string) (Level, string) {
+	if m := levelPrefixRegexp.FindStringSubmatchIndex(msg); m != nil {
+		var name string
+		if m[2] >= 0 {
+			name = msg[m[2]:m[3]]
+		} else {
+			name = msg[m[4]:m[5]]
+		}
+		if level, ok := stdlibLevel(name); ok {
+			return level, msg[m[1]:]
+		}
+	}
+	if m := levelFieldRegexp.FindStringSubmatchIndex(msg); m != nil {
+		if level, ok := stdlibLevel(msg[m[2]:m[3]]); ok {
+			return level, strings.TrimSpace(msg[:m[0]] + " " + msg[m[1]:])
+		}
+	}
+	return a.defaultLevel(), msg
+}
+
+func (a *StdlibAdapter) defaultLevel() Level {
+	if a.DefaultLevel == nil {
+		return ErrorLevel
+	}
+	return *a.DefaultLevel
+}
+
+// stdlibLevel returns the Level for a level name, including common names gocore has no level for.
+func stdlibLevel(name string) (Level, bool) {
+	switch strings.ToLower(name) {
+	case "trace":
+		return DebugLevel, true
+	case "notice":
+		return InfoLevel, true
+	case "err", "fatal", "panic", "crit", "critical":
+		return ErrorLevel, true
+	}
+	level, err := ParseLevel(name)
+	return level, err == nil
+}
+
 const (
 	logRegexpDate = `(?P<date>[0-9]{4}/[0-9]{2}/[0-9]{2})?[ ]?`
 	logRegexpTime = `(?P<time>[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?)?[ ]?`
-	logRegexpFile = `(?P<file>.+?:[0-9]+)?`
-	logRegexpMsg  = `(: )?(?P<msg>.*)`
+	logRegexpFile = `((?P<file>[^ ]+?:[0-9]+): )?`
+	logRegexpMsg  = `(?P<msg>.*)`
 )
 
 var (
 	logRegexp = regexp.MustCompile(logRegexpDate + logRegexpTime + logRegexpFile + logRegexpMsg)
+
+	// levelPrefixRegexp matches "[WARN] " or "INFO: " at the start of a message.
+	levelPrefixRegexp = regexp.MustCompile(`^\s*(?:\[([A-Za-z]+)\]:?|([A-Za-z]+):)\s*`)
+	// levelFieldRegexp matches a logfmt style "level=debug" anywhere in a message.
+	levelFieldRegexp = regexp.MustCompile(`(?:^|\s)level=([A-Za-z]+)(?:\s|$)`)
 )
 
 func (a *StdlibAdapter) subexps(line []byte) map[string]string {
+	if a.Prefix != "" {
+		line = []byte(strings.TrimPrefix(string(line), a.Prefix))
+	}
 	m := logRegexp.FindSubmatch(line)
 	if len(m) < len(logRegexp.SubexpNames()) {
 		return map[string]string{}
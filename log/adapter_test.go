@@ -0,0 +1,76 @@
+package log
+
+import (
+	"fmt"
+	stdlog "log"
+	"os"
+	"testing"
+)
+
+func TestStdlibAdapterLogsAtDefaultLevel(t *testing.T) {
+	buf := logWithBuffer()
+	stdlogger := stdlog.New(NewWriterToLog(GlobalLogger), "", 0)
+
+	stdlogger.Print("connection refused")
+	checkLogFormatMatches(t, "msg=\"connection refused\" level=error\n", buf)
+}
+
+func TestStdlibAdapterLiteralLogsAtErrorLevel(t *testing.T) {
+	buf := logWithBuffer()
+	stdlogger := stdlog.New(&StdlibAdapter{Logger: GlobalLogger}, "", 0)
+
+	stdlogger.Print("connection refused")
+	checkLogFormatMatches(t, "msg=\"connection refused\" level=error\n", buf)
+}
+
+func TestStdlibAdapterDetectsLevelPrefixes(t *testing.T) {
+	buf := logWithBuffer()
+	SetLevel(DebugLevel)
+	defer SetLevel(InfoLevel)
+	stdlogger := stdlog.New(NewStdlibAdapter(GlobalLogger, InfoLevel), "", 0)
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"[WARN] disk nearly full", "msg=\"disk nearly full\" level=warn\n"},
+		{"[debug]: cache miss", "msg=\"cache miss\" level=debug\n"},
+		{"ERROR: write failed", "msg=\"write failed\" level=error\n"},
+		{"Fatal: out of memory", "msg=\"out of memory\" level=error\n"},
+		{"retrying level=debug attempt=2", "msg=\"retrying attempt=2\" level=debug\n"},
+		{"http: TLS handshake error", "msg=\"http: TLS handshake error\" level=info\n"},
+		{"started", "msg=started level=info\n"},
+	}
+	for _, test := range tests {
+		stdlogger.Print(test.line)
+		checkLogFormatMatches(t, test.want, buf)
+	}
+}
+
+func TestStdlibAdapterKeepsCallerAndRemovesPrefix(t *testing.T) {
+	buf := logWithBuffer()
+	stdlogger := stdlog.New(NewStdlibAdapter(GlobalLogger, InfoLevel), "", stdlog.Lshortfile)
+	stdlogger.Print("[WARN] slow query")
+	want := fmt.Sprintf("caller=adapter_test.go:%d msg=\"slow query\" level=warn\n", lineAbove())
+	checkLogFormatMatches(t, want, buf)
+
+	stdlogger = stdlog.New(&StdlibAdapter{Logger: GlobalLogger, Prefix: "worker: "}, "worker: ", 0)
+	stdlogger.Print("started")
+	checkLogFormatMatches(t, "msg=started level=error\n", buf)
+}
+
+func TestRedirectStdlibLog(t *testing.T) {
+	buf := logWithBuffer()
+	defer stdlog.SetFlags(stdlog.Flags())
+	defer stdlog.SetPrefix(stdlog.Prefix())
+	defer stdlog.SetOutput(os.Stderr)
+
+	errorLog := RedirectStdlibLog(GlobalLogger, WarnLevel)
+	stdlog.Print("deprecated config")
+	want := fmt.Sprintf("caller=adapter_test.go:%d msg=\"deprecated config\" level=warn\n", lineAbove())
+	checkLogFormatMatches(t, want, buf)
+
+	errorLog.Print("http: TLS handshake error")
+	want = fmt.Sprintf("caller=adapter_test.go:%d msg=\"http: TLS handshake error\" level=warn\n", lineAbove())
+	checkLogFormatMatches(t, want, buf)
+}
@@ -0,0 +1,179 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrAsyncWriterClosed is returned when writing to a closed AsyncWriter.
+var ErrAsyncWriterClosed = errors.New("async log writer closed")
+
+// OverflowPolicy decides what an AsyncWriter does with a line when its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for space in the queue.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the line being written.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest queued line to make room.
+	OverflowDropOldest
+)
+
+// AsyncWriter is an io.Writer that queues lines and writes them to an underlying writer
+// from a background goroutine, so a slow output does not stall callers.
+//
+// Use it with the logger constructors, e.g. JSONLoggerTo(NewAsyncWriter(os.Stderr, 1024, OverflowDropOldest)),
+// and Close it on shutdown to drain the queue.
+type AsyncWriter struct {
+	dropped uint64 // accessed atomically, first for alignment
+
+	out    io.Writer
+	policy OverflowPolicy
+	queue  chan asyncLine
+	stop   chan struct{}
+	done   chan struct{}
+
+	mu       sync.Mutex
+	finished *sync.Cond
+	// lines are numbered as written; those through finishedThrough, and those in finishedAhead,
+	// have been written to out or dropped.
+	seq             uint64
+	finishedThrough uint64
+	finishedAhead   map[uint64]bool
+	closed          bool
+	err             error
+}
+
+// asyncLine is a queued line and its number, for Flush to tell when it has been written.
+type asyncLine struct {
+	seq  uint64
+	data []byte
+}
+
+// NewAsyncWriter returns an AsyncWriter queueing up to size lines for out.
+func NewAsyncWriter(out io.Writer, size int, policy OverflowPolicy) *AsyncWriter {
+	w := &AsyncWriter{
+		out:    out,
+		policy: policy,
+		queue:  make(chan asyncLine, size),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+
+		finishedAhead: map[uint64]bool{},
+	}
+	w.finished = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// Write queues a copy of p. It only blocks when the queue is full and the policy is OverflowBlock.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return 0, ErrAsyncWriterClosed
+	}
+	w.seq++
+	line := asyncLine{seq: w.seq, data: append([]byte(nil), p...)}
+	w.mu.Unlock()
+
+	switch w.policy {
+	case OverflowDropNewest:
+		select {
+		case w.queue <- line:
+		default:
+			w.drop(line)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case w.queue <- line:
+				return len(p), nil
+			default:
+			}
+			select {
+			case oldest := <-w.queue:
+				w.drop(oldest)
+			default:
+			}
+		}
+	default:
+		w.queue <- line
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of lines dropped because the queue was full.
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Flush waits until the lines queued before it was called have been written, returning the first error
+// from the underlying writer since the last Flush.
+func (w *AsyncWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for through := w.seq; w.finishedThrough < through; {
+		w.finished.Wait()
+	}
+	err := w.err
+	w.err = nil
+	return err
+}
+
+// Close stops accepting lines, drains the queue and stops the background goroutine.
+// It does not close the underlying writer.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	err := w.Flush()
+	close(w.stop)
+	<-w.done
+	return err
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	for {
+		select {
+		case line := <-w.queue:
+			_, err := w.out.Write(line.data)
+			w.finish(line.seq, err)
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *AsyncWriter) drop(line asyncLine) {
+	atomic.AddUint64(&w.dropped, 1)
+	w.finish(line.seq, nil)
+}
+
+// finish marks a line as written or dropped. Lines dropped from the queue may finish before earlier ones.
+func (w *AsyncWriter) finish(seq uint64, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	if seq != w.finishedThrough+1 {
+		w.finishedAhead[seq] = true
+		return
+	}
+	w.finishedThrough = seq
+	for w.finishedAhead[w.finishedThrough+1] {
+		delete(w.finishedAhead, w.finishedThrough+1)
+		w.finishedThrough++
+	}
+	w.finished.Broadcast()
+}
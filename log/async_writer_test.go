@@ -0,0 +1,172 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAsyncWriterFlush(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := NewAsyncWriter(&buf, 16, OverflowBlock)
+	logger := LogfmtLoggerTo(w)
+	logger.hideTimestamp = true
+
+	logger.LogInfoMessage("one")
+	logger.LogInfoMessage("two")
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	checkLogFormatMatches(t, "msg=one level=info\nmsg=two level=info\n", &buf)
+}
+
+func TestAsyncWriterFlushIgnoresLaterLines(t *testing.T) {
+	out := newGatedWriter()
+	w := NewAsyncWriter(out, 16, OverflowBlock)
+	w.Write([]byte("1 "))
+	<-out.started
+
+	flushed := make(chan struct{})
+	go func() {
+		w.Flush()
+		close(flushed)
+	}()
+	time.Sleep(10 * time.Millisecond) // for Flush to start waiting
+	w.Write([]byte("2 "))
+	out.openFor(1)
+
+	select {
+	case <-flushed:
+	case <-time.After(time.Second):
+		t.Fatal("want Flush to return once the lines before it were written")
+	}
+	if want, have := "1 ", out.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	out.open()
+	w.Close()
+}
+
+func TestAsyncWriterReportsErrorsOnce(t *testing.T) {
+	out := &failingWriter{err: errors.New("disk full")}
+	w := NewAsyncWriter(out, 16, OverflowBlock)
+	w.Write([]byte("lost"))
+	if err := w.Flush(); err != out.err {
+		t.Errorf("want %v, have %v", out.err, err)
+	}
+
+	out.setErr(nil)
+	w.Write([]byte("written"))
+	if err := w.Flush(); err != nil {
+		t.Errorf("want no error once the writer recovers, have %v", err)
+	}
+	w.Close()
+}
+
+func TestAsyncWriterDropNewest(t *testing.T) {
+	out := newGatedWriter()
+	w := NewAsyncWriter(out, 2, OverflowDropNewest)
+	writeBehindGate(w, out)
+
+	if want, have := uint64(2), w.Dropped(); want != have {
+		t.Errorf("want %d dropped, have %d", want, have)
+	}
+	out.open()
+	w.Close()
+	if want, have := "1 2 3 ", out.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestAsyncWriterDropOldest(t *testing.T) {
+	out := newGatedWriter()
+	w := NewAsyncWriter(out, 2, OverflowDropOldest)
+	writeBehindGate(w, out)
+
+	if want, have := uint64(2), w.Dropped(); want != have {
+		t.Errorf("want %d dropped, have %d", want, have)
+	}
+	out.open()
+	w.Close()
+	if want, have := "1 4 5 ", out.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestAsyncWriterClosed(t *testing.T) {
+	w := NewAsyncWriter(&bytes.Buffer{}, 2, OverflowBlock)
+	w.Close()
+	if _, err := w.Write([]byte("late")); err != ErrAsyncWriterClosed {
+		t.Errorf("want %v, have %v", ErrAsyncWriterClosed, err)
+	}
+}
+
+// writeBehindGate writes 5 lines to a size 2 queue while the first is stuck being written.
+func writeBehindGate(w *AsyncWriter, out *gatedWriter) {
+	w.Write([]byte("1 "))
+	<-out.started
+	for _, line := range []string{"2 ", "3 ", "4 ", "5 "} {
+		w.Write([]byte(line))
+	}
+}
+
+// gatedWriter blocks writes until opened, signalling when the first write starts.
+type gatedWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	started chan struct{}
+	gate    chan struct{}
+	once    sync.Once
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{started: make(chan struct{}), gate: make(chan struct{})}
+}
+
+func (g *gatedWriter) Write(p []byte) (int, error) {
+	g.once.Do(func() { close(g.started) })
+	<-g.gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.Write(p)
+}
+
+func (g *gatedWriter) open() {
+	close(g.gate)
+}
+
+// openFor lets n writes through, waiting for each to start.
+func (g *gatedWriter) openFor(n int) {
+	for i := 0; i < n; i++ {
+		g.gate <- struct{}{}
+	}
+}
+
+func (g *gatedWriter) String() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.String()
+}
+
+// failingWriter returns err from writes while it is set.
+type failingWriter struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return 0, f.err
+	}
+	return len(p), nil
+}
+
+func (f *failingWriter) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
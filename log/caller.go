@@ -0,0 +1,69 @@
+package log
+
+import (
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// CallerMode controls whether CoreLogger annotates lines with where they were logged from.
+type CallerMode int
+
+const (
+	// CallerNone adds no caller fields.
+	CallerNone CallerMode = iota
+	// CallerFileLine adds a "caller" field such as "billing/charge.go:42".
+	CallerFileLine
+	// CallerFileLineFunction also adds a "caller_function" field such as "billing.Charge".
+	CallerFileLineFunction
+)
+
+// logPackageDir is the directory holding this package's source, used to skip its frames.
+var logPackageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return path.Dir(file)
+}()
+
+// callerKeyvals returns the caller fields for the first frame outside of the logging packages.
+func callerKeyvals(mode CallerMode) []interface{} {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isLoggingFrame(frame) {
+			keyvals := []interface{}{"caller", shortFile(frame.File) + ":" + strconv.Itoa(frame.Line)}
+			if mode == CallerFileLineFunction {
+				keyvals = append(keyvals, "caller_function", shortFunction(frame.Function))
+			}
+			return keyvals
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// isLoggingFrame reports whether a frame is inside this package (excluding its tests),
+// or the standard library log and log/slog packages that can write through it.
+func isLoggingFrame(frame runtime.Frame) bool {
+	if path.Dir(frame.File) == logPackageDir && !strings.HasSuffix(frame.File, "_test.go") {
+		return true
+	}
+	return strings.HasPrefix(frame.Function, "log.") || strings.HasPrefix(frame.Function, "log/slog.")
+}
+
+// shortFile trims a path to its last directory and file name. Frame paths always use forward slashes.
+func shortFile(file string) string {
+	dir, name := path.Split(file)
+	return path.Join(path.Base(dir), name)
+}
+
+// shortFunction trims the import path from a function name, leaving package.Function.
+func shortFunction(function string) string {
+	if i := strings.LastIndex(function, "/"); i >= 0 {
+		return function[i+1:]
+	}
+	return function
+}
@@ -0,0 +1,38 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+func TestCallerFromCoreLogger(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	logger.SetCaller(CallerFileLine)
+
+	logger.LogInfoMessage("hello")
+	want := fmt.Sprintf("msg=hello caller=log/caller_test.go:%d level=info\n", lineAbove())
+	checkLogFormatMatches(t, want, &buf)
+
+	logger.With("foo", "bar").LogError("boom")
+	want = fmt.Sprintf("foo=bar msg=boom caller=log/caller_test.go:%d level=error\n", lineAbove())
+	checkLogFormatMatches(t, want, &buf)
+}
+
+func TestCallerFromGlobals(t *testing.T) {
+	buf := logWithBuffer()
+	GlobalLogger.(*CoreLogger).SetCaller(CallerFileLineFunction)
+
+	LogWarnMessage("hello")
+	want := fmt.Sprintf("msg=hello caller=log/caller_test.go:%d caller_function=log.TestCallerFromGlobals level=warn\n", lineAbove())
+	checkLogFormatMatches(t, want, buf)
+}
+
+// lineAbove returns the line before the one it was called from.
+func lineAbove() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line - 1
+}
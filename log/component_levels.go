@@ -0,0 +1,88 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ComponentKey is the standard field used to pick a per-component level, e.g. logger.With("component", "billing").
+const ComponentKey = "component"
+
+// defaultComponent is the key in a level spec for components without their own entry.
+const defaultComponent = "*"
+
+// ComponentLevels is a table of minimum levels keyed by component name, consulted at log time.
+// Components without an entry use the table default if set, otherwise the logger's own level.
+// Entries, including the default, take precedence over the logger's level, so changing that at runtime,
+// e.g. through the coreapi level handler, doesn't affect the components they cover.
+type ComponentLevels struct {
+	mu           sync.RWMutex
+	levels       map[string]Level
+	defaultLevel *Level
+}
+
+func NewComponentLevels() *ComponentLevels {
+	return &ComponentLevels{levels: map[string]Level{}}
+}
+
+// ParseComponentLevels builds a table from a spec such as "billing=debug,db=warn", with "*=<level>" setting the default.
+func ParseComponentLevels(spec string) (*ComponentLevels, error) {
+	cls := NewComponentLevels()
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid component level %q, want component=level", entry)
+		}
+		level, err := ParseLevel(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		cls.Set(strings.TrimSpace(parts[0]), level)
+	}
+	return cls, nil
+}
+
+// ComponentLevelsFromEnv parses the spec held in an environment variable. An unset variable gives an empty table.
+func ComponentLevelsFromEnv(envVar string) (*ComponentLevels, error) {
+	return ParseComponentLevels(os.Getenv(envVar))
+}
+
+// Set the level for a component; "*" sets the default.
+func (cls *ComponentLevels) Set(component string, level Level) {
+	cls.mu.Lock()
+	defer cls.mu.Unlock()
+	if component == defaultComponent {
+		cls.defaultLevel = &level
+		return
+	}
+	cls.levels[component] = level
+}
+
+// LevelFor returns the level for a component, and whether the table has one for it.
+func (cls *ComponentLevels) LevelFor(component string) (Level, bool) {
+	cls.mu.RLock()
+	defer cls.mu.RUnlock()
+	if level, ok := cls.levels[component]; ok {
+		return level, true
+	}
+	if cls.defaultLevel != nil {
+		return *cls.defaultLevel, true
+	}
+	return InfoLevel, false
+}
+
+// componentFrom returns the value of ComponentKey in keyvals, if present.
+func componentFrom(keyvals []interface{}) (string, bool) {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if key, ok := keyvals[i].(string); ok && key == ComponentKey {
+			return fmt.Sprint(keyvals[i+1]), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,123 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LogFormatEnvVar selects the format used by SetupAutoLoggerTo: "console", "json" or "logfmt".
+const LogFormatEnvVar = "LOG_FORMAT"
+
+// consoleMessageWidth pads messages so the fields after them line up.
+const consoleMessageWidth = 40
+
+var consoleLevelColors = map[string]int{
+	"debug": 90, // gray
+	"info":  34, // blue
+	"warn":  33, // yellow
+	"error": 31, // red
+}
+
+// consoleLogger is a go-kit logger rendering human-friendly lines: timestamp, level, msg, then key=value pairs.
+type consoleLogger struct {
+	writer io.Writer
+	color  bool
+	format Format
+}
+
+// ConsoleLoggerTo returns a logger in a human-friendly format for local development,
+// coloured when writing to a terminal.
+func ConsoleLoggerTo(writer io.Writer) *CoreLogger {
+	return NewCoreLogger(&consoleLogger{writer: writer, color: useColor(writer), format: DefaultFormat})
+}
+
+// AutoLoggerTo returns a logger in the format named by the LOG_FORMAT environment variable,
+// or if unset, console format when writing to a terminal and JSON otherwise.
+func AutoLoggerTo(writer io.Writer) *CoreLogger {
+	switch strings.ToLower(os.Getenv(LogFormatEnvVar)) {
+	case "console":
+		return ConsoleLoggerTo(writer)
+	case "json":
+		return JSONLoggerTo(writer)
+	case "logfmt":
+		return LogfmtLoggerTo(writer)
+	}
+	if isTerminal(writer) {
+		return ConsoleLoggerTo(writer)
+	}
+	return JSONLoggerTo(writer)
+}
+
+func (cl *consoleLogger) withFormat(format Format) formattedOutput {
+	formatted := *cl
+	formatted.format = format
+	return &formatted
+}
+
+func (cl *consoleLogger) Log(keyvals ...interface{}) error {
+	msg, level, timestamp, fields := splitLogKeyvals(cl.format, keyvals)
+
+	buf := bytes.Buffer{}
+	if !timestamp.IsZero() {
+		buf.WriteString(cl.colorize(90, cl.format.timestamp(timestamp)))
+		buf.WriteByte(' ')
+	}
+	if level != "" {
+		buf.WriteString(cl.colorize(consoleLevelColors[level], fmt.Sprintf("%-5s", strings.ToUpper(level))))
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(msg)
+	if len(fields) > 0 && len(msg) < consoleMessageWidth {
+		buf.WriteString(strings.Repeat(" ", consoleMessageWidth-len(msg)))
+	}
+	for i := 0; i < len(fields); i += 2 {
+		buf.WriteByte(' ')
+		buf.WriteString(cl.colorize(36, fmt.Sprint(fields[i])))
+		buf.WriteByte('=')
+		buf.WriteString(consoleValue(fields[i+1]))
+	}
+	buf.WriteByte('\n')
+
+	_, err := cl.writer.Write(buf.Bytes())
+	return err
+}
+
+func (cl *consoleLogger) colorize(color int, s string) string {
+	if !cl.color || color == 0 {
+		return s
+	}
+	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", color, s)
+}
+
+// consoleValue formats a value, quoting it if it would be ambiguous.
+func consoleValue(value interface{}) string {
+	s := fmt.Sprint(value)
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func useColor(writer io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(writer)
+}
+
+// isTerminal reports whether the writer is a character device such as a terminal.
+func isTerminal(writer io.Writer) bool {
+	file, ok := writer.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
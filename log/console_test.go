@@ -0,0 +1,70 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"testing"
+)
+
+func TestConsoleLogger(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := ConsoleLoggerTo(&buf)
+	logger.hideTimestamp = true
+
+	logger.With("path", "/foo").LogWarnMessage("slow request", "took", "3 s", "status", 200)
+	checkLogFormatMatches(t, "WARN  slow request                             path=/foo took=\"3 s\" status=200\n", &buf)
+
+	logger.LogInfoMessage("no fields")
+	checkLogFormatMatches(t, "INFO  no fields\n", &buf)
+}
+
+func TestConsoleLoggerWithFormat(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := ConsoleLoggerTo(&buf)
+	logger.SetFormat(GCPFormat)
+
+	logger.LogWarnMessage("slow request", "took", 3)
+	want := regexp.MustCompile(`^\S+Z WARN  slow request                             took=3\n$`)
+	if have := buf.String(); !want.MatchString(have) {
+		t.Errorf("want match for %v, have %q", want, have)
+	}
+}
+
+func TestConsoleLoggerFormatNotSharedWithParent(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := ConsoleLoggerTo(&buf)
+	logger.hideTimestamp = true
+
+	child := logger.With("path", "/foo").(*CoreLogger)
+	child.SetFormat(GCPFormat)
+	child.LogWarnMessage("child line")
+	checkLogFormatMatches(t, "WARN  child line                               path=/foo\n", &buf)
+
+	logger.LogWarnMessage("parent line")
+	checkLogFormatMatches(t, "WARN  parent line\n", &buf)
+}
+
+func TestConsoleLoggerColor(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := NewCoreLogger(&consoleLogger{writer: &buf, color: true, format: DefaultFormat})
+	logger.hideTimestamp = true
+
+	logger.LogErrorMessage("boom")
+	checkLogFormatMatches(t, "\x1b[31mERROR\x1b[0m boom\n", &buf)
+}
+
+func TestAutoLoggerUsesEnvironment(t *testing.T) {
+	defer os.Unsetenv(LogFormatEnvVar)
+	buf := bytes.Buffer{}
+
+	os.Setenv(LogFormatEnvVar, "console")
+	if _, ok := AutoLoggerTo(&buf).Logger.(*consoleLogger); !ok {
+		t.Errorf("expected console logger")
+	}
+
+	os.Unsetenv(LogFormatEnvVar)
+	if _, ok := AutoLoggerTo(&buf).Logger.(*consoleLogger); ok {
+		t.Errorf("expected JSON logger when not writing to a terminal")
+	}
+}
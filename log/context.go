@@ -0,0 +1,24 @@
+package log
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the Logger.
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the Logger carried by ctx, or GlobalLogger if there is none.
+func FromContext(ctx context.Context) Logger {
+	if logger, ok := ContextLogger(ctx); ok {
+		return logger
+	}
+	return GlobalLogger
+}
+
+// ContextLogger returns the Logger carried by ctx, and whether there was one.
+func ContextLogger(ctx context.Context) (Logger, bool) {
+	logger, ok := ctx.Value(contextKey{}).(Logger)
+	return logger, ok
+}
@@ -6,41 +6,140 @@ import (
 	"github.com/go-kit/kit/log"
 )
 
+// CoreLogger is the Logger implementation writing to a go-kit logger.
+// Loggers created from one via With/SetStandardFields share its settings as they were at the time;
+// the level, component levels, sampler and redactor are shared by pointer, so changes to them apply to all.
+// The Set methods, other than SetLevel, aren't safe to call while the logger is in use:
+// configure a logger before logging with it, or change the level at runtime through its AtomicLevel.
 type CoreLogger struct {
 	log.Logger
 	hideTimestamp bool
+	level         *AtomicLevel
+	components    *ComponentLevels
+	component     string
+	sampler       *Sampler
+	redactor      *Redactor
+	caller        CallerMode
+	errorStacks   bool
+	format        Format
+	output        formattedOutput
+	fields        []interface{} // standard fields, for rebuilding Logger around a reformatted output
+	writeHooks    []writeHook
 }
 
 func NewCoreLogger(l log.Logger) *CoreLogger {
-	return &CoreLogger{Logger: l}
+	output, _ := l.(formattedOutput)
+	return &CoreLogger{Logger: l, level: NewAtomicLevel(InfoLevel), format: DefaultFormat, output: output}
+}
+
+func (cl *CoreLogger) LogDebugMessage(message string, keyvalues ...interface{}) {
+	cl.LogDebug(append(keyvalues, "msg", message)...)
 }
 
 func (cl *CoreLogger) LogInfoMessage(message string, keyvalues ...interface{}) {
 	cl.LogInfo(append(keyvalues, "msg", message)...)
 }
 
+func (cl *CoreLogger) LogWarnMessage(message string, keyvalues ...interface{}) {
+	cl.LogWarn(append(keyvalues, "msg", message)...)
+}
+
 func (cl *CoreLogger) LogErrorMessage(message string, keyvalues ...interface{}) {
 	cl.LogError(append(keyvalues, "msg", message)...)
 }
 
+func (cl *CoreLogger) LogDebug(keyvals ...interface{}) {
+	cl.logAt(DebugLevel, keyvals)
+}
+
 func (cl *CoreLogger) LogInfo(keyvals ...interface{}) {
-	if len(keyvals) == 1 {
-		keyvals = []interface{}{"msg", keyvals[0]}
-	}
-	cl.Logger.Log(encodeCompoundValues(append(cl.logTimestamp(keyvals), "level", "info")...)...)
+	cl.logAt(InfoLevel, keyvals)
+}
+
+func (cl *CoreLogger) LogWarn(keyvals ...interface{}) {
+	cl.logAt(WarnLevel, keyvals)
 }
 
 func (cl *CoreLogger) LogError(keyvals ...interface{}) {
-	if len(keyvals) == 1 {
-		keyvals = []interface{}{"msg", keyvals[0]}
+	cl.logAt(ErrorLevel, keyvals)
+}
+
+// SetLevel sets the minimum Level logged; anything below it is dropped before encoding.
+func (cl *CoreLogger) SetLevel(level Level) {
+	cl.level.SetLevel(level)
+}
+
+// Level returns the minimum Level logged.
+func (cl *CoreLogger) Level() Level {
+	return cl.level.Level()
+}
+
+// AtomicLevel returns the shared level, for changing it at runtime.
+func (cl *CoreLogger) AtomicLevel() *AtomicLevel {
+	return cl.level
+}
+
+// SetComponentLevels sets a per-component level table, used in place of the logger's level for
+// loggers with a "component" standard field.
+func (cl *CoreLogger) SetComponentLevels(components *ComponentLevels) {
+	cl.components = components
+}
+
+// SetSampler enables sampling of repeated lines; summaries of dropped lines are written through this logger.
+func (cl *CoreLogger) SetSampler(sampler *Sampler) {
+	sampler.mu.Lock()
+	sampler.emit = cl.write
+	sampler.mu.Unlock()
+	cl.sampler = sampler
+}
+
+// SetRedactor masks sensitive keys and values in every line, and in standard fields set afterwards.
+func (cl *CoreLogger) SetRedactor(redactor *Redactor) {
+	cl.redactor = redactor
+}
+
+// SetCaller annotates lines with the file and line, and optionally function, they were logged from.
+func (cl *CoreLogger) SetCaller(mode CallerMode) {
+	cl.caller = mode
+}
+
+// SetErrorStacks adds a "<key>_stack" field for error values carrying a stack trace.
+func (cl *CoreLogger) SetErrorStacks(enabled bool) {
+	cl.errorStacks = enabled
+}
+
+// SetFormat sets the key names, timestamp layout and level values written, e.g. to DatadogFormat.
+func (cl *CoreLogger) SetFormat(format Format) {
+	cl.format = format.withDefaults()
+	if cl.output != nil {
+		cl.output = cl.output.withFormat(cl.format)
+		cl.Logger = log.With(cl.output, cl.fields...)
 	}
-	cl.Logger.Log(encodeCompoundValues(append(cl.logTimestamp(keyvals), "level", "error")...)...)
 }
 
 func (cl *CoreLogger) SetStandardFields(keyvals ...interface{}) Logger {
-	kitLogger := log.With(cl.Logger, keyvals...)
+	fields := keyvals
+	if cl.redactor != nil {
+		fields = cl.redactor.Redact(keyvals)
+	}
+	fields = lazyStandardFields(fields, cl.encodeStandardField)
+	kitLogger := log.With(cl.Logger, fields...)
 	newLogger := NewCoreLogger(kitLogger)
 	newLogger.hideTimestamp = cl.hideTimestamp
+	newLogger.level = cl.level
+	newLogger.components = cl.components
+	newLogger.component = cl.component
+	newLogger.sampler = cl.sampler
+	newLogger.redactor = cl.redactor
+	newLogger.caller = cl.caller
+	newLogger.errorStacks = cl.errorStacks
+	newLogger.format = cl.format
+	newLogger.output = cl.output
+	newLogger.fields = append(cl.fields[:len(cl.fields):len(cl.fields)], fields...)
+	newLogger.writeHooks = cl.writeHooks
+	if component, ok := componentFrom(keyvals); ok {
+		newLogger.component = component
+	}
 	return newLogger
 }
 
@@ -48,13 +147,69 @@ func (cl *CoreLogger) With(keyvals ...interface{}) Logger {
 	return cl.SetStandardFields(keyvals...)
 }
 
-func (cl *CoreLogger) logTimestamp(keyvals []interface{}) []interface{} {
-	if !cl.hideTimestamp {
-		return append(keyvals, "timestamp", defaultTimeUTC())
+func (cl *CoreLogger) logAt(level Level, keyvals []interface{}) {
+	if level < cl.minLevel() {
+		return
 	}
-	return keyvals
+	if len(keyvals) == 1 {
+		keyvals = []interface{}{"msg", keyvals[0]}
+	}
+	if cl.sampler != nil {
+		keyvals = resolveMessage(keyvals)
+		if !cl.sampler.allow(level, keyvals) {
+			return
+		}
+	}
+	if cl.caller != CallerNone {
+		keyvals = append(keyvals, callerKeyvals(cl.caller)...)
+	}
+	cl.write(level, keyvals)
 }
 
-func defaultTimeUTC() string {
-	return time.Now().UTC().Format(time.RFC3339)
+func (cl *CoreLogger) write(level Level, line []interface{}) {
+	keyvals := expandErrors(resolveLazy(line), cl.errorStacks)
+	cl.format.renameMessage(keyvals)
+	keyvals = append(cl.logTimestamp(encodeCompoundValues(keyvals...)), cl.format.LevelKey, cl.format.levelValue(level))
+	if cl.redactor != nil {
+		keyvals = cl.redactor.Redact(keyvals)
+	}
+	cl.Logger.Log(keyvals...)
+	for _, hook := range cl.writeHooks {
+		hook(level, cl.component, line)
+	}
+}
+
+// withWriteHook returns a copy of the logger calling hook with each line it, or a logger created from it, writes.
+func (cl *CoreLogger) withWriteHook(hook writeHook) (Logger, bool) {
+	hooked := *cl
+	hooked.writeHooks = append(append([]writeHook{}, cl.writeHooks...), hook)
+	return &hooked, true
+}
+
+// encodeStandardField encodes a lazy standard field's value when it is evaluated.
+func (cl *CoreLogger) encodeStandardField(value interface{}) interface{} {
+	value = encodeCompoundValue(value)
+	if cl.redactor != nil {
+		value = cl.redactor.scrub(value)
+	}
+	return value
+}
+
+func (cl *CoreLogger) minLevel() Level {
+	if cl.components != nil && cl.component != "" {
+		if level, ok := cl.components.LevelFor(cl.component); ok {
+			return level
+		}
+	}
+	return cl.level.Level()
+}
+
+func (cl *CoreLogger) logTimestamp(keyvals []interface{}) []interface{} {
+	if cl.hideTimestamp {
+		return keyvals
+	}
+	if cl.output != nil {
+		return append(keyvals, cl.format.TimestampKey, time.Now()) // formatted by the output
+	}
+	return append(keyvals, cl.format.TimestampKey, cl.format.timestamp(time.Now()))
 }
@@ -0,0 +1,217 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DedupeLogger is a Logger collapsing repeated lines. Within each window, the first line with a given
+// level, msg and values of the selected keys is logged; identical lines after it are dropped and counted.
+// When the window ends, a line is logged for each that repeated, with its fields plus "repeated",
+// the number dropped, and "first_seen" and "last_seen" timestamps.
+// Loggers derived with With share the window, so lines from them are collapsed together.
+type DedupeLogger struct {
+	logger Logger
+	*deduper
+}
+
+type deduper struct {
+	window time.Duration
+	keys   []string
+
+	mu         sync.Mutex
+	windowEnd  time.Time
+	lines      map[string]*dedupeLine
+	flushTimer *time.Timer
+}
+
+type dedupeLine struct {
+	log       func(keyvals ...interface{})
+	keyvals   []interface{}
+	repeated  int
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// NewDedupeLogger returns a DedupeLogger logging to logger, collapsing lines identical in level, msg and
+// the values of keys within each window.
+func NewDedupeLogger(logger Logger, window time.Duration, keys ...string) *DedupeLogger {
+	return &DedupeLogger{
+		logger: logger,
+		deduper: &deduper{
+			window: window,
+			keys:   keys,
+			lines:  map[string]*dedupeLine{},
+		},
+	}
+}
+
+func (dl *DedupeLogger) LogDebugMessage(message string, keyvals ...interface{}) {
+	dl.logMessage(DebugLevel, dl.logger.LogDebugMessage, message, keyvals)
+}
+
+func (dl *DedupeLogger) LogInfoMessage(message string, keyvals ...interface{}) {
+	dl.logMessage(InfoLevel, dl.logger.LogInfoMessage, message, keyvals)
+}
+
+func (dl *DedupeLogger) LogWarnMessage(message string, keyvals ...interface{}) {
+	dl.logMessage(WarnLevel, dl.logger.LogWarnMessage, message, keyvals)
+}
+
+func (dl *DedupeLogger) LogErrorMessage(message string, keyvals ...interface{}) {
+	dl.logMessage(ErrorLevel, dl.logger.LogErrorMessage, message, keyvals)
+}
+
+func (dl *DedupeLogger) LogDebug(keyvals ...interface{}) {
+	dl.log(DebugLevel, dl.logger.LogDebug, keyvals)
+}
+
+func (dl *DedupeLogger) LogInfo(keyvals ...interface{}) {
+	dl.log(InfoLevel, dl.logger.LogInfo, keyvals)
+}
+
+func (dl *DedupeLogger) LogWarn(keyvals ...interface{}) {
+	dl.log(WarnLevel, dl.logger.LogWarn, keyvals)
+}
+
+func (dl *DedupeLogger) LogError(keyvals ...interface{}) {
+	dl.log(ErrorLevel, dl.logger.LogError, keyvals)
+}
+
+// With returns a DedupeLogger for the underlying logger with the standard fields added.
+func (dl *DedupeLogger) With(keyvals ...interface{}) Logger {
+	return &DedupeLogger{logger: dl.logger.With(keyvals...), deduper: dl.deduper}
+}
+
+// withWriteHook returns a DedupeLogger sharing the window, for the underlying logger with hook added, if it supports one.
+func (dl *DedupeLogger) withWriteHook(hook writeHook) (Logger, bool) {
+	h, ok := dl.logger.(writeHookable)
+	if !ok {
+		return nil, false
+	}
+	logger, ok := h.withWriteHook(hook)
+	if !ok {
+		return nil, false
+	}
+	return &DedupeLogger{logger: logger, deduper: dl.deduper}, true
+}
+
+// Flush ends the current window, logging lines that repeated in it.
+func (dl *DedupeLogger) Flush() {
+	dl.mu.Lock()
+	pending := dl.resetLocked(time.Now())
+	dl.mu.Unlock()
+
+	emitRepeated(pending)
+}
+
+func (dl *DedupeLogger) logMessage(level Level, log func(string, ...interface{}), message string, keyvals []interface{}) {
+	if dl.enabled(level) && !dl.allow(level, message, keyvals, func(kv ...interface{}) { log(message, kv...) }) {
+		return
+	}
+	log(message, keyvals...)
+}
+
+func (dl *DedupeLogger) log(level Level, log func(...interface{}), keyvals []interface{}) {
+	if len(keyvals) == 1 {
+		keyvals = []interface{}{"msg", keyvals[0]}
+	}
+	if dl.enabled(level) && !dl.allow(level, messageFrom(keyvals), keyvals, log) {
+		return
+	}
+	log(keyvals...)
+}
+
+// enabled reports whether the underlying logger would log at level, so dropped lines aren't counted.
+func (dl *DedupeLogger) enabled(level Level) bool {
+	if cl, ok := dl.logger.(*CoreLogger); ok {
+		return level >= cl.minLevel()
+	}
+	return true
+}
+
+// allow records an occurrence of the line and reports whether it is the first in the window.
+func (d *deduper) allow(level Level, msg string, keyvals []interface{}, log func(...interface{})) bool {
+	key := d.key(level, msg, keyvals)
+	now := time.Now()
+
+	d.mu.Lock()
+	var pending []*dedupeLine
+	if !now.Before(d.windowEnd) {
+		pending = d.resetLocked(now)
+	}
+	line, ok := d.lines[key]
+	if !ok {
+		d.lines[key] = &dedupeLine{
+			log:       log,
+			keyvals:   append([]interface{}{}, keyvals...),
+			firstSeen: now,
+			lastSeen:  now,
+		}
+	} else {
+		line.repeated++
+		line.lastSeen = now
+		if d.flushTimer == nil {
+			d.flushTimer = time.AfterFunc(d.windowEnd.Sub(now), d.flush)
+		}
+	}
+	d.mu.Unlock()
+
+	emitRepeated(pending)
+	return !ok
+}
+
+// flush ends the current window if it has elapsed, logging lines that repeated in it.
+func (d *deduper) flush() {
+	now := time.Now()
+	d.mu.Lock()
+	var pending []*dedupeLine
+	if !now.Before(d.windowEnd) {
+		pending = d.resetLocked(now)
+	}
+	d.mu.Unlock()
+
+	emitRepeated(pending)
+}
+
+// resetLocked starts a new window, returning the lines that repeated in the previous one.
+func (d *deduper) resetLocked(now time.Time) []*dedupeLine {
+	var pending []*dedupeLine
+	for _, line := range d.lines {
+		if line.repeated > 0 {
+			pending = append(pending, line)
+		}
+	}
+	if d.flushTimer != nil {
+		d.flushTimer.Stop()
+		d.flushTimer = nil
+	}
+	d.lines = map[string]*dedupeLine{}
+	d.windowEnd = now.Add(d.window)
+	return pending
+}
+
+// key identifies a line by level, msg and the values of the selected keys.
+func (d *deduper) key(level Level, msg string, keyvals []interface{}) string {
+	key := level.String() + "\x00" + msg
+	for _, selected := range d.keys {
+		key += "\x00"
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			if k, ok := keyvals[i].(string); ok && k == selected {
+				key += fmt.Sprint(keyvals[i+1])
+			}
+		}
+	}
+	return key
+}
+
+func emitRepeated(lines []*dedupeLine) {
+	for _, line := range lines {
+		line.log(append(line.keyvals,
+			"repeated", line.repeated,
+			"first_seen", line.firstSeen.UTC().Format(RFC3339Milli),
+			"last_seen", line.lastSeen.UTC().Format(RFC3339Milli),
+		)...)
+	}
+}
@@ -0,0 +1,99 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDedupeLoggerCollapsesRepeatedLines(t *testing.T) {
+	buf := logWithBuffer()
+	logger := NewDedupeLogger(GlobalLogger, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		logger.LogErrorMessage("connection refused", "attempt", i)
+	}
+	logger.LogWarnMessage("connection refused")
+	logger.LogError("timed out")
+	logger.LogError("timed out")
+	checkLogFormatMatches(t, "attempt=0 msg=\"connection refused\" level=error\nmsg=\"connection refused\" level=warn\nmsg=\"timed out\" level=error\n", buf)
+
+	logger.Flush()
+	want := regexp.MustCompile(`^(attempt=0 repeated=2 first_seen=\S+ last_seen=\S+ msg="connection refused"|msg="timed out" repeated=1 first_seen=\S+ last_seen=\S+) level=error\n`)
+	for _, line := range regexp.MustCompile(`(?m)^.*\n`).FindAllString(buf.String(), -1) {
+		if !want.MatchString(line) {
+			t.Errorf("want match for %v, have %q", want, line)
+		}
+	}
+	if want, have := 2, len(regexp.MustCompile(`\n`).FindAllString(buf.String(), -1)); want != have {
+		t.Errorf("want %d repeated lines, have %d in %q", want, have, buf.String())
+	}
+	buf.Reset()
+
+	// a new window logs the line again
+	logger.LogErrorMessage("connection refused", "attempt", 3)
+	checkLogFormatMatches(t, "attempt=3 msg=\"connection refused\" level=error\n", buf)
+}
+
+func TestDedupeLoggerDistinguishesSelectedKeys(t *testing.T) {
+	buf := logWithBuffer()
+	logger := NewDedupeLogger(GlobalLogger, time.Hour, "host")
+
+	logger.LogErrorMessage("connection refused", "host", "db1")
+	logger.LogErrorMessage("connection refused", "host", "db2")
+	logger.With("request_id", 1).LogErrorMessage("connection refused", "host", "db1")
+	checkLogFormatMatches(t, "host=db1 msg=\"connection refused\" level=error\nhost=db2 msg=\"connection refused\" level=error\n", buf)
+}
+
+func TestDedupeLoggerDistinguishesErrorMessages(t *testing.T) {
+	buf := logWithBuffer()
+	logger := NewDedupeLogger(GlobalLogger, time.Hour)
+
+	logger.LogError(errors.New("disk full"))
+	logger.LogError(errors.New("connection refused"))
+	logger.LogError(errors.New("disk full"))
+	checkLogFormatMatches(t, "msg=\"disk full\" level=error\nmsg=\"connection refused\" level=error\n", buf)
+
+	logger.Flush()
+	if want := regexp.MustCompile(`^msg="disk full" repeated=1 first_seen=\S+ last_seen=\S+ level=error\n$`); !want.MatchString(buf.String()) {
+		t.Errorf("want match for %v, have %q", want, buf.String())
+	}
+}
+
+func TestDedupeLoggerFlushesWhenWindowEnds(t *testing.T) {
+	buf := &lockedBuffer{}
+	coreLogger := LogfmtLoggerTo(buf)
+	coreLogger.hideTimestamp = true
+	logger := NewDedupeLogger(coreLogger, 20*time.Millisecond)
+
+	logger.LogInfo("retrying")
+	logger.LogInfo("retrying")
+	logger.LogDebug("retrying") // below the logger's level, so not counted
+
+	time.Sleep(100 * time.Millisecond)
+	want := regexp.MustCompile(`^msg=retrying level=info\nmsg=retrying repeated=1 first_seen=\S+ last_seen=\S+ level=info\n$`)
+	if have := buf.String(); !want.MatchString(have) {
+		t.Errorf("want match for %v, have %q", want, have)
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe for lines logged from timers.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,95 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// errorChainSeparator joins the messages of an error chain into a single field value.
+const errorChainSeparator = " -> "
+
+// expandErrors replaces each error value in keyvals with its message, adding "<key>_type" and,
+// for wrapped errors, "<key>_chain" with the messages of the unwrapped errors.
+// With stacks, "<key>_stack" is added when an error in the chain carries a stack trace.
+// An error logged as the message, e.g. LogError(err), is replaced by its message alone.
+func expandErrors(keyvals []interface{}, stacks bool) []interface{} {
+	expanded := make([]interface{}, 0, len(keyvals))
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 == len(keyvals) {
+			expanded = append(expanded, keyvals[i]) // missing a value
+			break
+		}
+		err, ok := keyvals[i+1].(error)
+		if !ok || isNilValue(err) {
+			expanded = append(expanded, keyvals[i], keyvals[i+1])
+			continue
+		}
+		if key, ok := keyvals[i].(string); ok && key == "msg" {
+			expanded = append(expanded, key, err.Error())
+			continue
+		}
+		expanded = append(expanded, expandError(fmt.Sprint(keyvals[i]), err, stacks)...)
+	}
+	return expanded
+}
+
+func expandError(key string, err error, stacks bool) []interface{} {
+	keyvals := []interface{}{key, err.Error(), key + "_type", fmt.Sprintf("%T", err)}
+
+	chain := unwrapChain(err)
+	if len(chain) > 1 {
+		messages := make([]string, len(chain))
+		for i, e := range chain {
+			messages[i] = e.Error()
+		}
+		keyvals = append(keyvals, key+"_chain", strings.Join(messages, errorChainSeparator))
+	}
+	if stacks {
+		if stack, ok := stackTrace(chain); ok {
+			keyvals = append(keyvals, key+"_stack", stack)
+		}
+	}
+	return keyvals
+}
+
+// unwrapChain returns err followed by every error it wraps, depth first,
+// following both Unwrap() error and the Unwrap() []error of errors.Join.
+func unwrapChain(err error) []error {
+	chain := []error{err}
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if inner := e.Unwrap(); inner != nil {
+			chain = append(chain, unwrapChain(inner)...)
+		}
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			if inner != nil {
+				chain = append(chain, unwrapChain(inner)...)
+			}
+		}
+	}
+	return chain
+}
+
+// stackTrace formats the stack of the innermost error with a StackTrace() method, as provided by
+// github.com/pkg/errors and similar packages.
+func stackTrace(chain []error) (string, bool) {
+	for i := len(chain) - 1; i >= 0; i-- {
+		method := reflect.ValueOf(chain[i]).MethodByName("StackTrace")
+		if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+			continue
+		}
+		return strings.TrimSpace(fmt.Sprintf("%+v", method.Call(nil)[0].Interface())), true
+	}
+	return "", false
+}
+
+func isNilValue(v interface{}) bool {
+	rvalue := reflect.ValueOf(v)
+	switch rvalue.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return rvalue.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLogErrorExpanded(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+
+	logger.LogErrorMessage("failed", "error", errors.New("boom"))
+	checkLogFormatMatches(t, "error=boom error_type=*errors.errorString msg=failed level=error\n", &buf)
+}
+
+func TestLogErrorAsMessageNotExpanded(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+
+	logger.LogError(errors.New("boom"))
+	checkLogFormatMatches(t, "msg=boom level=error\n", &buf)
+}
+
+func TestLogErrorChain(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+
+	err := &wrappedError{msg: "load config", err: &joinedError{errs: []error{errors.New("no file"), errors.New("no env")}}}
+	logger.LogErrorMessage("failed", "cause", err)
+	checkLogFormatMatches(t, "cause=\"load config: no file, no env\" cause_type=*log.wrappedError cause_chain=\"load config: no file, no env -> no file, no env -> no file -> no env\" msg=failed level=error\n", &buf)
+}
+
+func TestLogErrorStack(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+
+	err := &wrappedError{msg: "load config", err: stackError{}}
+	logger.LogErrorMessage("failed", "error", err)
+	if strings.Contains(buf.String(), "error_stack") {
+		t.Errorf("did not expect a stack without SetErrorStacks, have %s", buf.String())
+	}
+	buf.Reset()
+
+	logger.SetErrorStacks(true)
+	logger.LogErrorMessage("failed", "error", err)
+	if !strings.Contains(buf.String(), "error_stack=\"main.go:1\\nmain.go:2\"") {
+		t.Errorf("expected a stack, have %s", buf.String())
+	}
+}
+
+func TestLogNilError(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+
+	var err *wrappedError
+	logger.LogInfoMessage("ok", "error", err)
+	checkLogFormatMatches(t, "error=null msg=ok level=info\n", &buf)
+}
+
+type wrappedError struct {
+	msg string
+	err error
+}
+
+func (e *wrappedError) Error() string { return e.msg + ": " + e.err.Error() }
+func (e *wrappedError) Unwrap() error { return e.err }
+
+type joinedError struct {
+	errs []error
+}
+
+func (e *joinedError) Error() string {
+	messages := []string{}
+	for _, err := range e.errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, ", ")
+}
+func (e *joinedError) Unwrap() []error { return e.errs }
+
+type stackError struct{}
+
+func (stackError) Error() string              { return "no file" }
+func (stackError) StackTrace() testStackTrace { return testStackTrace{"main.go:1", "main.go:2"} }
+
+type testStackTrace []string
+
+func (s testStackTrace) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, strings.Join(s, "\n"))
+}
@@ -0,0 +1,118 @@
+package log
+
+import (
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+)
+
+// RFC3339Milli is RFC3339 with millisecond precision.
+const RFC3339Milli = "2006-01-02T15:04:05.000Z07:00"
+
+// Format sets the key names, timestamp layout and level values CoreLogger writes.
+// Empty fields fall back to the defaults: "msg", "level", "timestamp", RFC3339 and Level.String().
+type Format struct {
+	MessageKey      string
+	LevelKey        string
+	TimestampKey    string
+	TimestampLayout string
+	LevelValues     map[Level]string
+}
+
+var (
+	// DefaultFormat is the format loggers use unless set otherwise.
+	DefaultFormat = Format{
+		MessageKey:      "msg",
+		LevelKey:        "level",
+		TimestampKey:    "timestamp",
+		TimestampLayout: time.RFC3339,
+	}
+
+	// DatadogFormat matches Datadog's reserved log attributes.
+	DatadogFormat = Format{
+		MessageKey:      "message",
+		LevelKey:        "status",
+		TimestampKey:    "timestamp",
+		TimestampLayout: RFC3339Milli,
+	}
+
+	// GCPFormat matches Google Cloud Logging's structured log fields and severities.
+	GCPFormat = Format{
+		MessageKey:      "message",
+		LevelKey:        "severity",
+		TimestampKey:    "timestamp",
+		TimestampLayout: time.RFC3339Nano,
+		LevelValues: map[Level]string{
+			DebugLevel: "DEBUG",
+			InfoLevel:  "INFO",
+			WarnLevel:  "WARNING",
+			ErrorLevel: "ERROR",
+		},
+	}
+
+	// ECSFormat matches Elastic Common Schema field names.
+	ECSFormat = Format{
+		MessageKey:      "message",
+		LevelKey:        "log.level",
+		TimestampKey:    "@timestamp",
+		TimestampLayout: RFC3339Milli,
+	}
+)
+
+// withDefaults fills empty fields from DefaultFormat.
+func (f Format) withDefaults() Format {
+	if f.MessageKey == "" {
+		f.MessageKey = DefaultFormat.MessageKey
+	}
+	if f.LevelKey == "" {
+		f.LevelKey = DefaultFormat.LevelKey
+	}
+	if f.TimestampKey == "" {
+		f.TimestampKey = DefaultFormat.TimestampKey
+	}
+	if f.TimestampLayout == "" {
+		f.TimestampLayout = DefaultFormat.TimestampLayout
+	}
+	return f
+}
+
+func (f Format) levelValue(level Level) string {
+	if value, ok := f.LevelValues[level]; ok {
+		return value
+	}
+	return level.String()
+}
+
+// levelName returns the Level name for a level value written in the format, or the value itself if unknown.
+func (f Format) levelName(value string) string {
+	for _, level := range []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel} {
+		if f.levelValue(level) == value {
+			return level.String()
+		}
+	}
+	return value
+}
+
+func (f Format) timestamp(t time.Time) string {
+	return t.UTC().Format(f.TimestampLayout)
+}
+
+// formattedOutput is implemented by outputs reading the message, level and timestamp back out of each line,
+// which need the Format they were written in. CoreLogger passes them the timestamp as a time.Time.
+type formattedOutput interface {
+	kitlog.Logger
+	// withFormat returns a copy of the output reading lines written in format, sharing its writer or connection.
+	withFormat(format Format) formattedOutput
+}
+
+// renameMessage replaces "msg" keys, used internally for messages, with the format's message key.
+func (f Format) renameMessage(keyvals []interface{}) {
+	if f.MessageKey == "msg" {
+		return
+	}
+	for i := 0; i < len(keyvals); i += 2 {
+		if key, ok := keyvals[i].(string); ok && key == "msg" {
+			keyvals[i] = f.MessageKey
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package log
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"time"
+)
+
+const (
+	gelfDefaultChunkSize = 1420
+	gelfMaxChunks        = 128
+	gelfChunkHeaderSize  = 12
+)
+
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// ErrGELFMessageTooLarge is returned when a message needs more than the 128 chunks GELF allows over UDP.
+var ErrGELFMessageTooLarge = errors.New("gelf message too large to chunk")
+
+var gelfInvalidFieldChars = regexp.MustCompile(`[^\w.\-]`)
+
+// GELFConfig configures a logger writing Graylog Extended Log Format messages.
+type GELFConfig struct {
+	// Network is "udp", with large messages chunked, or "tcp", with messages null byte delimited.
+	Network string
+	Address string
+	// Host defaults to the OS hostname.
+	Host string
+	// ChunkSize is the largest UDP datagram sent, header included; defaults to 1420.
+	ChunkSize int
+}
+
+// gelfLogger is a go-kit logger encoding lines as GELF 1.1 messages,
+// with fields other than msg, level and timestamp as additional fields.
+type gelfLogger struct {
+	conn   *reconnectingConn
+	config GELFConfig
+	format Format
+}
+
+// GELFLoggerTo returns a logger writing to a Graylog GELF input, connecting on the first write and reconnecting after failed ones.
+// Levels map to syslog severities; the message is the short_message and other fields, including standard
+// fields, are sent as additional "_" prefixed fields. Close the returned connection on shutdown.
+func GELFLoggerTo(config GELFConfig) (*CoreLogger, io.Closer, error) {
+	if config.Host == "" {
+		config.Host, _ = os.Hostname()
+	}
+	if config.ChunkSize <= gelfChunkHeaderSize {
+		config.ChunkSize = gelfDefaultChunkSize
+	}
+	conn, err := newReconnectingConn(config.Network, config.Address)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewCoreLogger(&gelfLogger{conn: conn, config: config, format: DefaultFormat}), conn, nil
+}
+
+func (gl *gelfLogger) withFormat(format Format) formattedOutput {
+	formatted := *gl
+	formatted.format = format
+	return &formatted
+}
+
+func (gl *gelfLogger) Log(keyvals ...interface{}) error {
+	msg, level, _, fields := splitLogKeyvals(gl.format, keyvals)
+	message, err := gl.encode(time.Now(), msg, level, fields)
+	if err != nil {
+		return err
+	}
+	if gl.conn.isStream() {
+		return gl.conn.write(append(message, 0))
+	}
+	chunks, err := gelfChunks(message, gl.config.ChunkSize)
+	if err != nil {
+		return err
+	}
+	return gl.conn.write(chunks...)
+}
+
+func (gl *gelfLogger) encode(t time.Time, msg, level string, fields []interface{}) ([]byte, error) {
+	if msg == "" {
+		msg = "-" // short_message is required to be non-empty
+	}
+	message := map[string]interface{}{
+		"version":       "1.1",
+		"host":          gl.config.Host,
+		"short_message": msg,
+		"timestamp":     float64(t.UnixNano()/int64(time.Millisecond)) / 1000,
+		"level":         syslogSeverity(level),
+	}
+	for i := 0; i < len(fields); i += 2 {
+		message[gelfFieldName(fmt.Sprint(fields[i]))] = gelfFieldValue(fields[i+1])
+	}
+	return json.Marshal(message)
+}
+
+// gelfFieldName returns an additional field name: "_" followed by word characters, dots and dashes.
+// "_id" is reserved, so is renamed.
+func gelfFieldName(key string) string {
+	name := "_" + gelfInvalidFieldChars.ReplaceAllString(key, "_")
+	if name == "_id" {
+		return "__id"
+	}
+	return name
+}
+
+// gelfFieldValue returns numbers as they are and anything else as a string,
+// as GELF additional fields may only be strings or numbers.
+func gelfFieldValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case string:
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+// gelfChunks splits a message into datagrams of at most chunkSize bytes,
+// each with a header of the magic bytes, a message id, its sequence number and the count.
+func gelfChunks(message []byte, chunkSize int) ([][]byte, error) {
+	if len(message) <= chunkSize {
+		return [][]byte{message}, nil
+	}
+	dataSize := chunkSize - gelfChunkHeaderSize
+	count := (len(message) + dataSize - 1) / dataSize
+	if count > gelfMaxChunks {
+		return nil, ErrGELFMessageTooLarge
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	chunks := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * dataSize
+		if end > len(message) {
+			end = len(message)
+		}
+		chunk := make([]byte, 0, gelfChunkHeaderSize+end-i*dataSize)
+		chunk = append(chunk, gelfChunkMagic...)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, message[i*dataSize:end]...)
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
@@ -0,0 +1,145 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGELFLoggerWritesMessageOverUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	logger, _, err := GELFLoggerTo(GELFConfig{Network: "udp", Address: conn.LocalAddr().String(), Host: "host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.SetStandardFields("service", "billing").LogErrorMessage("card declined", "id", 7, "card type", "visa")
+
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := map[string]interface{}{}
+	if err := json.Unmarshal(buf[:n], &message); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"version":       "1.1",
+		"host":          "host",
+		"short_message": "card declined",
+		"level":         float64(3),
+		"_service":      "billing",
+		"__id":          float64(7),
+		"_card_type":    "visa",
+	}
+	for key, value := range want {
+		if message[key] != value {
+			t.Errorf("%s: want %v, have %v", key, value, message[key])
+		}
+	}
+	if _, ok := message["timestamp"].(float64); !ok {
+		t.Errorf("want numeric timestamp, have %v", message["timestamp"])
+	}
+}
+
+func TestGELFLoggerChunksLargeUDPMessages(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	logger, _, err := GELFLoggerTo(GELFConfig{Network: "udp", Address: conn.LocalAddr().String(), ChunkSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.LogInfoMessage(strings.Repeat("x", 500))
+
+	var chunks [][]byte
+	buf := make([]byte, 2048)
+	for {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunk := append([]byte{}, buf[:n]...)
+		if len(chunk) > 100 || !bytes.HasPrefix(chunk, gelfChunkMagic) {
+			t.Fatalf("invalid chunk %q", chunk)
+		}
+		chunks = append(chunks, chunk)
+		if len(chunks) == int(chunk[11]) {
+			break
+		}
+	}
+
+	message := []byte{}
+	for i, chunk := range chunks {
+		if !bytes.Equal(chunk[2:10], chunks[0][2:10]) {
+			t.Errorf("chunk %d has a different message id", i)
+		}
+		if int(chunk[10]) != i {
+			t.Errorf("chunk %d has sequence number %d", i, chunk[10])
+		}
+		message = append(message, chunk[gelfChunkHeaderSize:]...)
+	}
+	decoded := map[string]interface{}{}
+	if err := json.Unmarshal(message, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := strings.Repeat("x", 500), decoded["short_message"]; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestGELFChunksRejectsTooManyChunks(t *testing.T) {
+	if _, err := gelfChunks(make([]byte, 129*10), gelfChunkHeaderSize+10); err != ErrGELFMessageTooLarge {
+		t.Errorf("want %v, have %v", ErrGELFMessageTooLarge, err)
+	}
+}
+
+func TestGELFLoggerDelimitsTCPMessages(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	logger, _, err := GELFLoggerTo(GELFConfig{Network: "tcp", Address: listener.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.LogInfoMessage("first")
+	logger.LogWarnMessage("second")
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for _, want := range []string{"first", "second"} {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		frame, err := reader.ReadBytes(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		message := map[string]interface{}{}
+		if err := json.Unmarshal(frame[:len(frame)-1], &message); err != nil {
+			t.Fatal(err)
+		}
+		if have := message["short_message"]; want != have {
+			t.Errorf("want %v, have %v", want, have)
+		}
+	}
+}
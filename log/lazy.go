@@ -0,0 +1,44 @@
+package log
+
+import kitlog "github.com/go-kit/kit/log"
+
+// Lazy is a log value computed only when a line is actually written, so filtered lines cost nothing.
+// As a standard field set via With it is evaluated for every line, like events.Event.AddDynamicField,
+// e.g. logger.With("elapsed", Lazy(func() interface{} { return time.Since(start) })).
+type Lazy func() interface{}
+
+// lazyFunc returns the function behind a Lazy value, or a plain func() interface{}.
+func lazyFunc(value interface{}) (func() interface{}, bool) {
+	switch fn := value.(type) {
+	case Lazy:
+		return fn, fn != nil
+	case func() interface{}:
+		return fn, fn != nil
+	}
+	return nil, false
+}
+
+// resolveLazy returns a copy of keyvals with lazy values evaluated.
+func resolveLazy(keyvals []interface{}) []interface{} {
+	resolved := make([]interface{}, len(keyvals))
+	copy(resolved, keyvals)
+	for i := 1; i < len(resolved); i += 2 {
+		if fn, ok := lazyFunc(resolved[i]); ok {
+			resolved[i] = fn()
+		}
+	}
+	return resolved
+}
+
+// lazyStandardFields returns a copy of keyvals with lazy values turned into go-kit Valuers, which it
+// evaluates on each line. Results are passed through encode, as standard fields skip the per-line processing.
+func lazyStandardFields(keyvals []interface{}, encode func(interface{}) interface{}) []interface{} {
+	fields := make([]interface{}, len(keyvals))
+	copy(fields, keyvals)
+	for i := 1; i < len(fields); i += 2 {
+		if fn, ok := lazyFunc(fields[i]); ok {
+			fields[i] = kitlog.Valuer(func() interface{} { return encode(fn()) })
+		}
+	}
+	return fields
+}
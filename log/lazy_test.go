@@ -0,0 +1,55 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLazyValueOnlyEvaluatedWhenLogged(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+
+	calls := 0
+	expensive := Lazy(func() interface{} {
+		calls++
+		return []int{1, 2}
+	})
+	logger.LogDebugMessage("filtered", "value", expensive)
+	if calls != 0 {
+		t.Errorf("lazy value evaluated for a filtered line")
+	}
+
+	logger.LogInfoMessage("logged", "value", expensive)
+	checkLogFormatMatches(t, "value=\"[1 2]\" msg=logged level=info\n", &buf)
+	if calls != 1 {
+		t.Errorf("want 1 evaluation, have %d", calls)
+	}
+}
+
+func TestLazyStandardFieldEvaluatedPerLine(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+
+	i := 0
+	l2 := logger.With("count", Lazy(func() interface{} {
+		i++
+		return i
+	}))
+	l2.LogInfoMessage("one")
+	checkLogFormatMatches(t, "count=1 msg=one level=info\n", &buf)
+	l2.LogInfoMessage("two")
+	checkLogFormatMatches(t, "count=2 msg=two level=info\n", &buf)
+}
+
+func TestLazyStandardFieldRedacted(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	logger.SetRedactor(NewRedactor().ScrubValues(EmailPattern))
+
+	l2 := logger.With("user", Lazy(func() interface{} { return "jane@example.com" }))
+	l2.LogInfoMessage("one")
+	checkLogFormatMatches(t, "user=[REDACTED] msg=one level=info\n", &buf)
+}
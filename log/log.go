@@ -14,9 +14,13 @@ var (
 
 // Logger represents a logger with inheritable context
 type Logger interface {
+	LogDebugMessage(message string, keyvalues ...interface{})
 	LogInfoMessage(message string, keyvalues ...interface{})
+	LogWarnMessage(message string, keyvalues ...interface{})
 	LogErrorMessage(message string, keyvalues ...interface{})
+	LogDebug(keyvals ...interface{})
 	LogInfo(keyvals ...interface{})
+	LogWarn(keyvals ...interface{})
 	LogError(keyvals ...interface{})
 	With(keyvals ...interface{}) Logger
 }
@@ -37,21 +41,57 @@ func SetupJSONLoggerTo(writer io.Writer) {
 	GlobalLogger = l
 }
 
+// Public initialization function to initialize the logger global.
+// Human-friendly console format, for local development.
+// This should be called before any goroutines using the logger are started
+func SetupConsoleLoggerTo(writer io.Writer) {
+	l := ConsoleLoggerTo(writer)
+	GlobalLogger = l
+}
+
+// Public initialization function to initialize the logger global.
+// Console format when writing to a terminal, JSON otherwise; LOG_FORMAT overrides this.
+// This should be called before any goroutines using the logger are started
+func SetupAutoLoggerTo(writer io.Writer) {
+	l := AutoLoggerTo(writer)
+	GlobalLogger = l
+}
+
+// Log a message to Debug, with optional keyvalues
+func LogDebugMessage(message string, keyvalues ...interface{}) {
+	GlobalLogger.LogDebugMessage(message, keyvalues...)
+}
+
 // Log a message to Info, with optional keyvalues
 func LogInfoMessage(message string, keyvalues ...interface{}) {
 	GlobalLogger.LogInfoMessage(message, keyvalues...)
 }
 
+// Log a message to Warn, with optional keyvalues
+func LogWarnMessage(message string, keyvalues ...interface{}) {
+	GlobalLogger.LogWarnMessage(message, keyvalues...)
+}
+
 // Log a message to Error, with optional keyvalues
 func LogErrorMessage(message string, keyvalues ...interface{}) {
 	GlobalLogger.LogErrorMessage(message, keyvalues...)
 }
 
+// Log a series of key, values to Debug
+func LogDebug(keyvals ...interface{}) {
+	GlobalLogger.LogDebug(keyvals...)
+}
+
 // Log a series of key, values to Info
 func LogInfo(keyvals ...interface{}) {
 	GlobalLogger.LogInfo(keyvals...)
 }
 
+// Log a series of key, values to Warn
+func LogWarn(keyvals ...interface{}) {
+	GlobalLogger.LogWarn(keyvals...)
+}
+
 // Log a series of key, values to Error
 func LogError(keyvals ...interface{}) {
 	GlobalLogger.LogError(keyvals...)
@@ -62,6 +102,20 @@ func SetStandardFields(keyvals ...interface{}) {
 	GlobalLogger = GlobalLogger.With(keyvals...)
 }
 
+// Sets the minimum level logged by the global logger, if it supports levels
+func SetLevel(level Level) {
+	if l, ok := GlobalLogger.(interface{ SetLevel(Level) }); ok {
+		l.SetLevel(level)
+	}
+}
+
+// Sets a per-component level table on the global logger, if it supports one
+func SetComponentLevels(components *ComponentLevels) {
+	if l, ok := GlobalLogger.(interface{ SetComponentLevels(*ComponentLevels) }); ok {
+		l.SetComponentLevels(components)
+	}
+}
+
 func JSONLoggerTo(writer io.Writer) *CoreLogger {
 	return NewCoreLogger(kitlog.NewJSONLogger(writer))
 }
@@ -81,17 +135,20 @@ func encodeCompoundValues(keyvals ...interface{}) []interface{} {
 	}
 
 	for i := 0; i < len(keyvals); i += 2 {
-		_, v := keyvals[i], keyvals[i+1]
-
-		rvalue := reflect.ValueOf(v)
-		switch rvalue.Kind() {
-		case reflect.Array, reflect.Chan, reflect.Func, reflect.Map, reflect.Slice, reflect.Struct:
-			keyvals[i+1] = fmt.Sprintf("%+v", v)
-		}
+		keyvals[i+1] = encodeCompoundValue(keyvals[i+1])
 	}
 	return keyvals
 }
 
+func encodeCompoundValue(v interface{}) interface{} {
+	rvalue := reflect.ValueOf(v)
+	switch rvalue.Kind() {
+	case reflect.Array, reflect.Chan, reflect.Func, reflect.Map, reflect.Slice, reflect.Struct:
+		return fmt.Sprintf("%+v", v)
+	}
+	return v
+}
+
 // Package-level default initialization of the logger.
 // Initializes it to a no-op implementation;
 // later calls can replace it by calling SetupLogger.
@@ -2,6 +2,7 @@ package log
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"testing"
 	"time"
@@ -37,6 +38,111 @@ func TestLogErrorMessageWithExtra(t *testing.T) {
 	checkLogFormatMatches(t, "bar=7.6 msg=\"my message\" level=error\n", buf)
 }
 
+func TestLogWarnMessageWithExtra(t *testing.T) {
+	buf := logWithBuffer()
+	LogWarnMessage("my message", "bar", 7.6)
+	checkLogFormatMatches(t, "bar=7.6 msg=\"my message\" level=warn\n", buf)
+}
+
+func TestLogDebugDroppedByDefault(t *testing.T) {
+	buf := logWithBuffer()
+	LogDebugMessage("my message")
+	checkLogFormatMatches(t, "", buf)
+}
+
+func TestSetLevel(t *testing.T) {
+	buf := logWithBuffer()
+	SetLevel(DebugLevel)
+	LogDebug("foo", "bar")
+	checkLogFormatMatches(t, "foo=bar level=debug\n", buf)
+
+	SetLevel(WarnLevel)
+	LogInfoMessage("my message")
+	checkLogFormatMatches(t, "", buf)
+	LogError("foo", "bar")
+	checkLogFormatMatches(t, "foo=bar level=error\n", buf)
+}
+
+func TestLevelInheritedByStandardFields(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	logger.SetLevel(ErrorLevel)
+	l2 := logger.With("foo", "bar")
+
+	l2.LogWarnMessage("uh oh")
+	checkLogFormatMatches(t, "", &buf)
+}
+
+func TestComponentLevels(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	components, err := ParseComponentLevels("billing=debug, db=error,*=warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.SetComponentLevels(components)
+
+	billing := logger.With("component", "billing")
+	billing.LogDebugMessage("charged")
+	checkLogFormatMatches(t, "component=billing msg=charged level=debug\n", &buf)
+
+	db := logger.With("component", "db").With("table", "users")
+	db.LogWarnMessage("slow query")
+	checkLogFormatMatches(t, "", &buf)
+
+	other := logger.With("component", "search")
+	other.LogInfoMessage("indexed")
+	checkLogFormatMatches(t, "", &buf)
+	other.LogWarnMessage("indexed")
+	checkLogFormatMatches(t, "component=search msg=indexed level=warn\n", &buf)
+}
+
+func TestComponentLevelsWithoutDefaultUseLoggerLevel(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	components, _ := ParseComponentLevels("billing=error")
+	logger.SetComponentLevels(components)
+
+	logger.With("component", "search").LogInfoMessage("indexed")
+	checkLogFormatMatches(t, "component=search msg=indexed level=info\n", &buf)
+}
+
+func TestComponentLevelsDefaultIgnoredWithoutComponent(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	components, _ := ParseComponentLevels("*=info")
+	logger.SetComponentLevels(components)
+	logger.SetLevel(DebugLevel)
+
+	logger.LogDebugMessage("started")
+	checkLogFormatMatches(t, "msg=started level=debug\n", &buf)
+	logger.With("component", "search").LogDebugMessage("indexed")
+	checkLogFormatMatches(t, "", &buf)
+}
+
+func TestParseComponentLevelsInvalid(t *testing.T) {
+	if _, err := ParseComponentLevels("billing"); err == nil {
+		t.Errorf("expected error for missing level")
+	}
+	if _, err := ParseComponentLevels("billing=loud"); err == nil {
+		t.Errorf("expected error for unknown level")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("WARN")
+	if err != nil || level != WarnLevel {
+		t.Errorf("want %v, have %v (%v)", WarnLevel, level, err)
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Errorf("expected error for unknown level")
+	}
+}
+
 func TestLogInfoWithCompoundTypeArray(t *testing.T) {
 	buf := logWithBuffer()
 	LogInfo("key", []string{"foo", "bar"})
@@ -89,6 +195,26 @@ func TestLogWithStandardFieldsMakesNewLogger(t *testing.T) {
 	checkLogFormatMatches(t, "foo=bar msg=\"uh oh\" level=error\n", &buf)
 }
 
+func TestLoggerFromContext(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	ctx := NewContext(context.Background(), logger.With("requestID", "abc"))
+
+	FromContext(ctx).LogInfoMessage("hello")
+	checkLogFormatMatches(t, "requestID=abc msg=hello level=info\n", &buf)
+}
+
+func TestLoggerFromContextFallsBackToGlobal(t *testing.T) {
+	buf := logWithBuffer()
+	if _, ok := ContextLogger(context.Background()); ok {
+		t.Errorf("did not expect a logger in an empty context")
+	}
+
+	FromContext(context.Background()).LogInfoMessage("hello")
+	checkLogFormatMatches(t, "msg=hello level=info\n", buf)
+}
+
 func TestJSONLog(t *testing.T) {
 	buf := bytes.Buffer{}
 	logger := JSONLoggerTo(&buf)
@@ -147,3 +273,39 @@ type testTypeStringer struct {
 func (t testTypeStringer) String() string {
 	return t.Bar
 }
+
+func TestSetFormat(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := JSONLoggerTo(&buf)
+	logger.hideTimestamp = true
+	logger.SetFormat(GCPFormat)
+
+	logger.With("foo", "bar").LogWarnMessage("something", "key", 4)
+	checkLogFormatMatches(t, "{\"foo\":\"bar\",\"key\":4,\"message\":\"something\",\"severity\":\"WARNING\"}\n", &buf)
+}
+
+func TestSetFormatFillsDefaults(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	logger.SetFormat(Format{LevelKey: "lvl"})
+
+	logger.LogInfoMessage("something")
+	checkLogFormatMatches(t, "msg=something lvl=info\n", &buf)
+}
+
+func TestSetFormatTimestamp(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := JSONLoggerTo(&buf)
+	logger.SetFormat(ECSFormat)
+	logger.LogInfoMessage("something")
+
+	line := map[string]string{}
+	json.Unmarshal(buf.Bytes(), &line)
+	if _, err := time.Parse(RFC3339Milli, line["@timestamp"]); err != nil {
+		t.Errorf("timestamp %q not in millisecond layout: %v", line["@timestamp"], err)
+	}
+	if want, have := "info", line["log.level"]; want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
@@ -0,0 +1,234 @@
+// Package logtest provides a log.Logger that records entries, for asserting on logging in tests.
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/intercom/gocore/log"
+)
+
+// Entry is a single recorded log line.
+type Entry struct {
+	Level log.Level
+	Msg   string
+	// Fields holds every key and value other than "msg", including those inherited via With,
+	// with lazy values, log.Lazy or func() interface{}, evaluated as a log.CoreLogger would.
+	// Where a key appears more than once, the latest value wins.
+	Fields map[string]interface{}
+	// Keyvals holds every key and value in the order they would be logged, with inherited fields first.
+	Keyvals []interface{}
+}
+
+func (e Entry) String() string {
+	parts := []string{fmt.Sprintf("level=%s msg=%q", e.Level, e.Msg)}
+	for i := 0; i+1 < len(e.Keyvals); i += 2 {
+		if e.Keyvals[i] != "msg" {
+			parts = append(parts, fmt.Sprintf("%v=%v", e.Keyvals[i], e.Keyvals[i+1]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Logger is a log.Logger recording every entry at every level.
+// Loggers created from it via With record to the same entries.
+type Logger struct {
+	recorder *recorder
+	fields   []interface{}
+}
+
+type recorder struct {
+	mu         sync.Mutex
+	entries    []Entry
+	failOnErr  bool
+	unexpected []Entry // error-level entries to fail the test with, with FailOnError
+}
+
+func New() *Logger {
+	return &Logger{recorder: &recorder{}}
+}
+
+// FailOnError makes any error-level entry logged from now on fail t when it completes,
+// so entries logged from other goroutines are reported safely.
+func (l *Logger) FailOnError(t testing.TB) *Logger {
+	l.recorder.mu.Lock()
+	defer l.recorder.mu.Unlock()
+	l.recorder.failOnErr = true
+	t.Cleanup(func() {
+		l.recorder.mu.Lock()
+		defer l.recorder.mu.Unlock()
+		for _, entry := range l.recorder.unexpected {
+			t.Errorf("unexpected error log: %s", entry)
+		}
+		l.recorder.failOnErr, l.recorder.unexpected = false, nil
+	})
+	return l
+}
+
+func (l *Logger) LogDebugMessage(message string, keyvalues ...interface{}) {
+	l.LogDebug(append(keyvalues, "msg", message)...)
+}
+
+func (l *Logger) LogInfoMessage(message string, keyvalues ...interface{}) {
+	l.LogInfo(append(keyvalues, "msg", message)...)
+}
+
+func (l *Logger) LogWarnMessage(message string, keyvalues ...interface{}) {
+	l.LogWarn(append(keyvalues, "msg", message)...)
+}
+
+func (l *Logger) LogErrorMessage(message string, keyvalues ...interface{}) {
+	l.LogError(append(keyvalues, "msg", message)...)
+}
+
+func (l *Logger) LogDebug(keyvals ...interface{}) {
+	l.record(log.DebugLevel, keyvals)
+}
+
+func (l *Logger) LogInfo(keyvals ...interface{}) {
+	l.record(log.InfoLevel, keyvals)
+}
+
+func (l *Logger) LogWarn(keyvals ...interface{}) {
+	l.record(log.WarnLevel, keyvals)
+}
+
+func (l *Logger) LogError(keyvals ...interface{}) {
+	l.record(log.ErrorLevel, keyvals)
+}
+
+func (l *Logger) With(keyvals ...interface{}) log.Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keyvals))
+	fields = append(fields, l.fields...)
+	fields = append(fields, padKeyvals(keyvals)...)
+	return &Logger{recorder: l.recorder, fields: fields}
+}
+
+// Entries returns every recorded entry, oldest first.
+func (l *Logger) Entries() []Entry {
+	return l.Filter(func(Entry) bool { return true })
+}
+
+// EntriesAt returns the recorded entries at a level.
+func (l *Logger) EntriesAt(level log.Level) []Entry {
+	return l.Filter(func(e Entry) bool { return e.Level == level })
+}
+
+// Filter returns the recorded entries matching fn.
+func (l *Logger) Filter(fn func(Entry) bool) []Entry {
+	l.recorder.mu.Lock()
+	defer l.recorder.mu.Unlock()
+	entries := []Entry{}
+	for _, entry := range l.recorder.entries {
+		if fn(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Reset discards the recorded entries.
+func (l *Logger) Reset() {
+	l.recorder.mu.Lock()
+	defer l.recorder.mu.Unlock()
+	l.recorder.entries = nil
+}
+
+// AssertLogged fails t unless an entry was logged at level with msg and the given fields.
+// Field values match if they are deeply equal or print the same.
+func (l *Logger) AssertLogged(t testing.TB, level log.Level, msg string, keyvals ...interface{}) {
+	t.Helper()
+	if len(l.Filter(matching(level, msg, keyvals))) == 0 {
+		t.Errorf("no %s entry with msg %q and fields %v, have:\n%s", level, msg, keyvals, l.dump())
+	}
+}
+
+// AssertNotLogged fails t if an entry was logged at level with msg.
+func (l *Logger) AssertNotLogged(t testing.TB, level log.Level, msg string) {
+	t.Helper()
+	if entries := l.Filter(matching(level, msg, nil)); len(entries) > 0 {
+		t.Errorf("unexpected %s entry with msg %q: %s", level, msg, entries[0])
+	}
+}
+
+// AssertNoErrors fails t if any error-level entry was logged.
+func (l *Logger) AssertNoErrors(t testing.TB) {
+	t.Helper()
+	for _, entry := range l.EntriesAt(log.ErrorLevel) {
+		t.Errorf("unexpected error log: %s", entry)
+	}
+}
+
+func (l *Logger) record(level log.Level, keyvals []interface{}) {
+	if len(keyvals) == 1 {
+		keyvals = []interface{}{"msg", keyvals[0]}
+	}
+	all := append(append([]interface{}{}, l.fields...), padKeyvals(keyvals)...)
+	for i := 1; i < len(all); i += 2 {
+		switch fn := all[i].(type) {
+		case log.Lazy:
+			if fn != nil {
+				all[i] = fn()
+			}
+		case func() interface{}:
+			if fn != nil {
+				all[i] = fn()
+			}
+		}
+	}
+
+	entry := Entry{Level: level, Fields: map[string]interface{}{}, Keyvals: all}
+	for i := 0; i < len(all); i += 2 {
+		key := fmt.Sprint(all[i])
+		if key == "msg" {
+			entry.Msg = fmt.Sprint(all[i+1])
+			continue
+		}
+		entry.Fields[key] = all[i+1]
+	}
+
+	l.recorder.mu.Lock()
+	defer l.recorder.mu.Unlock()
+	l.recorder.entries = append(l.recorder.entries, entry)
+	if l.recorder.failOnErr && level == log.ErrorLevel {
+		l.recorder.unexpected = append(l.recorder.unexpected, entry)
+	}
+}
+
+func (l *Logger) dump() string {
+	lines := []string{}
+	for _, entry := range l.Entries() {
+		lines = append(lines, "\t"+entry.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func matching(level log.Level, msg string, keyvals []interface{}) func(Entry) bool {
+	keyvals = padKeyvals(keyvals)
+	return func(e Entry) bool {
+		if e.Level != level || e.Msg != msg {
+			return false
+		}
+		for i := 0; i < len(keyvals); i += 2 {
+			have, ok := e.Fields[fmt.Sprint(keyvals[i])]
+			if !ok || !valuesMatch(keyvals[i+1], have) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func valuesMatch(want, have interface{}) bool {
+	return reflect.DeepEqual(want, have) || fmt.Sprint(want) == fmt.Sprint(have)
+}
+
+func padKeyvals(keyvals []interface{}) []interface{} {
+	if len(keyvals)%2 == 1 {
+		return append(keyvals[:len(keyvals):len(keyvals)], nil) // missing a value
+	}
+	return keyvals
+}
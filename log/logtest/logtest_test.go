@@ -0,0 +1,117 @@
+package logtest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/intercom/gocore/log"
+	"github.com/intercom/gocore/log/logtest"
+)
+
+func TestRecordsEntriesWithInheritedFields(t *testing.T) {
+	logger := logtest.New()
+	logger.With("requestID", "abc").LogWarnMessage("slow", "ms", 300)
+	logger.LogInfo("started")
+
+	entries := logger.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("want 2 entries, have %d", len(entries))
+	}
+	if want, have := "slow", entries[0].Msg; want != have {
+		t.Errorf("want msg %q, have %q", want, have)
+	}
+	if want, have := "abc", entries[0].Fields["requestID"]; want != have {
+		t.Errorf("want requestID %v, have %v", want, have)
+	}
+	if want, have := "started", entries[1].Msg; want != have {
+		t.Errorf("want msg %q, have %q", want, have)
+	}
+
+	logger.AssertLogged(t, log.WarnLevel, "slow", "requestID", "abc", "ms", int64(300))
+	logger.AssertNotLogged(t, log.ErrorLevel, "slow")
+	logger.AssertNoErrors(t)
+}
+
+func TestEntriesAt(t *testing.T) {
+	logger := logtest.New()
+	logger.LogDebugMessage("a")
+	logger.LogErrorMessage("b")
+	logger.LogDebugMessage("c")
+
+	if want, have := 2, len(logger.EntriesAt(log.DebugLevel)); want != have {
+		t.Errorf("want %d debug entries, have %d", want, have)
+	}
+	logger.Reset()
+	if want, have := 0, len(logger.Entries()); want != have {
+		t.Errorf("want %d entries after reset, have %d", want, have)
+	}
+}
+
+func TestAssertionsFail(t *testing.T) {
+	logger := logtest.New()
+	logger.LogErrorMessage("boom", "code", 1)
+
+	ft := &fakeT{}
+	logger.AssertLogged(ft, log.ErrorLevel, "boom", "code", 2)
+	logger.AssertNotLogged(ft, log.ErrorLevel, "boom")
+	logger.AssertNoErrors(ft)
+	if want, have := 3, len(ft.errors); want != have {
+		t.Errorf("want %d failures, have %d: %v", want, have, ft.errors)
+	}
+}
+
+func TestFailOnError(t *testing.T) {
+	ft := &fakeT{}
+	logger := logtest.New().FailOnError(ft)
+	logger.With("foo", "bar").LogInfoMessage("fine")
+	done := make(chan struct{})
+	go func() {
+		logger.With("foo", "bar").LogErrorMessage("boom")
+		close(done)
+	}()
+	<-done
+
+	if want, have := 0, len(ft.errors); want != have {
+		t.Fatalf("want %d failures before the test completes, have %d: %v", want, have, ft.errors)
+	}
+	ft.cleanup()
+	if want, have := 1, len(ft.errors); want != have {
+		t.Fatalf("want %d failures, have %d: %v", want, have, ft.errors)
+	}
+	logger.LogErrorMessage("after the test")
+	if want, have := 1, len(ft.errors); want != have {
+		t.Errorf("want %d failures, have %d: %v", want, have, ft.errors)
+	}
+}
+
+// fakeT records failures instead of failing the test, and runs cleanups when told to.
+type fakeT struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeT) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+// cleanup runs the cleanups, as the test completing would.
+func (f *fakeT) cleanup() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+	f.cleanups = nil
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestLazyValuesEvaluated(t *testing.T) {
+	logger := logtest.New()
+	logger.With("count", log.Lazy(func() interface{} { return 3 })).LogInfoMessage("hello", "size", func() interface{} { return 4 })
+
+	logger.AssertLogged(t, log.InfoLevel, "hello", "count", 3, "size", 4)
+}
@@ -0,0 +1,139 @@
+package log
+
+// LogLinesMetric is the counter metrics.LogLineCounter increments for each line, tagged with "level".
+const LogLinesMetric = "log.lines"
+
+// LineCounter counts the lines a MetricsLogger logs, such as metrics.LogLineCounter counting them as LogLinesMetric.
+type LineCounter interface {
+	// CountLine counts a line logged at level, by component if MetricsLogger.SetComponentTag is enabled, otherwise "".
+	CountLine(level Level, component string)
+}
+
+// writeHook is called with each line a logger writes, after level filtering and sampling,
+// along with the component of the logger writing it.
+type writeHook func(level Level, component string, keyvals []interface{})
+
+// writeHookable is implemented by loggers that can report the lines they write.
+type writeHookable interface {
+	// withWriteHook returns a logger calling hook with each line it writes, and whether that is supported.
+	withWriteHook(hook writeHook) (Logger, bool)
+}
+
+// MetricsLogger is a Logger counting the lines it logs in a LineCounter,
+// so log volume, such as the error rate, can be graphed and alerted on without parsing logs.
+// Wrapping a CoreLogger, or a DedupeLogger around one, only lines actually written are counted,
+// after level filtering, sampling and deduplication; other loggers have every line counted.
+type MetricsLogger struct {
+	logger    Logger
+	counter   *lineCounter
+	hooked    bool
+	component string
+}
+
+// lineCounter counts lines in a LineCounter, with the component if enabled.
+type lineCounter struct {
+	counter      LineCounter
+	tagComponent bool
+}
+
+// NewMetricsLogger returns a MetricsLogger logging to logger and counting lines in counter,
+// e.g. metrics.NewLogLineCounter(recorder).
+func NewMetricsLogger(logger Logger, counter LineCounter) *MetricsLogger {
+	ml := &MetricsLogger{
+		logger:  logger,
+		counter: &lineCounter{counter: counter},
+	}
+	if h, ok := logger.(writeHookable); ok {
+		if hooked, ok := h.withWriteHook(ml.counter.count); ok {
+			ml.logger, ml.hooked = hooked, true
+		}
+	}
+	return ml
+}
+
+// SetComponentTag sets whether counts are also tagged with "component", from a standard field or the line.
+// Set it before the logger is used.
+func (ml *MetricsLogger) SetComponentTag(enabled bool) {
+	ml.counter.tagComponent = enabled
+}
+
+func (ml *MetricsLogger) LogDebugMessage(message string, keyvals ...interface{}) {
+	ml.count(DebugLevel, keyvals)
+	ml.logger.LogDebugMessage(message, keyvals...)
+}
+
+func (ml *MetricsLogger) LogInfoMessage(message string, keyvals ...interface{}) {
+	ml.count(InfoLevel, keyvals)
+	ml.logger.LogInfoMessage(message, keyvals...)
+}
+
+func (ml *MetricsLogger) LogWarnMessage(message string, keyvals ...interface{}) {
+	ml.count(WarnLevel, keyvals)
+	ml.logger.LogWarnMessage(message, keyvals...)
+}
+
+func (ml *MetricsLogger) LogErrorMessage(message string, keyvals ...interface{}) {
+	ml.count(ErrorLevel, keyvals)
+	ml.logger.LogErrorMessage(message, keyvals...)
+}
+
+func (ml *MetricsLogger) LogDebug(keyvals ...interface{}) {
+	ml.count(DebugLevel, keyvals)
+	ml.logger.LogDebug(keyvals...)
+}
+
+func (ml *MetricsLogger) LogInfo(keyvals ...interface{}) {
+	ml.count(InfoLevel, keyvals)
+	ml.logger.LogInfo(keyvals...)
+}
+
+func (ml *MetricsLogger) LogWarn(keyvals ...interface{}) {
+	ml.count(WarnLevel, keyvals)
+	ml.logger.LogWarn(keyvals...)
+}
+
+func (ml *MetricsLogger) LogError(keyvals ...interface{}) {
+	ml.count(ErrorLevel, keyvals)
+	ml.logger.LogError(keyvals...)
+}
+
+// With returns a MetricsLogger for the underlying logger with the standard fields added.
+func (ml *MetricsLogger) With(keyvals ...interface{}) Logger {
+	derived := *ml
+	derived.logger = ml.logger.With(keyvals...)
+	if component, ok := componentFrom(keyvals); ok {
+		derived.component = component
+	}
+	return &derived
+}
+
+// withWriteHook returns a MetricsLogger for the underlying logger with hook added, if it supports one.
+func (ml *MetricsLogger) withWriteHook(hook writeHook) (Logger, bool) {
+	h, ok := ml.logger.(writeHookable)
+	if !ok {
+		return nil, false
+	}
+	logger, ok := h.withWriteHook(hook)
+	if !ok {
+		return nil, false
+	}
+	derived := *ml
+	derived.logger = logger
+	return &derived, true
+}
+
+// count counts a line before logging it, unless the underlying logger counts the lines it writes.
+func (ml *MetricsLogger) count(level Level, keyvals []interface{}) {
+	if !ml.hooked {
+		ml.counter.count(level, ml.component, keyvals)
+	}
+}
+
+func (lc *lineCounter) count(level Level, component string, keyvals []interface{}) {
+	if !lc.tagComponent {
+		component = ""
+	} else if c, ok := componentFrom(keyvals); ok {
+		component = c
+	}
+	lc.counter.CountLine(level, component)
+}
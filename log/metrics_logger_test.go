@@ -0,0 +1,87 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+// lineCounts is a LineCounter keeping counts in memory.
+type lineCounts map[countedLine]int
+
+type countedLine struct {
+	level     Level
+	component string
+}
+
+func (c lineCounts) CountLine(level Level, component string) {
+	c[countedLine{level, component}]++
+}
+
+func (c lineCounts) total() int {
+	total := 0
+	for _, count := range c {
+		total += count
+	}
+	return total
+}
+
+func TestMetricsLoggerCountsLinesByLevel(t *testing.T) {
+	buf := logWithBuffer()
+	counts := lineCounts{}
+	logger := NewMetricsLogger(GlobalLogger, counts)
+
+	logger.LogInfoMessage("started")
+	logger.LogErrorMessage("failed")
+	logger.LogError("msg", "failed again")
+	logger.LogDebugMessage("dropped") // below the logger's level
+
+	if want, have := 1, counts[countedLine{level: InfoLevel}]; want != have {
+		t.Errorf("want %d info lines, have %d", want, have)
+	}
+	if want, have := 2, counts[countedLine{level: ErrorLevel}]; want != have {
+		t.Errorf("want %d error lines, have %d", want, have)
+	}
+	if want, have := 3, counts.total(); want != have {
+		t.Errorf("want %d lines, have %d", want, have)
+	}
+	checkLogFormatMatches(t, "msg=started level=info\nmsg=failed level=error\nmsg=\"failed again\" level=error\n", buf)
+}
+
+func TestMetricsLoggerTagsComponent(t *testing.T) {
+	logWithBuffer()
+	counts := lineCounts{}
+	logger := NewMetricsLogger(GlobalLogger, counts)
+	logger.SetComponentTag(true)
+
+	billing := logger.With(ComponentKey, "billing")
+	billing.LogWarnMessage("retrying")
+	billing.With("user_id", 7).LogWarnMessage("retrying")
+	logger.LogWarnMessage("retrying", ComponentKey, "db")
+	logger.LogWarnMessage("retrying")
+
+	if want, have := 2, counts[countedLine{WarnLevel, "billing"}]; want != have {
+		t.Errorf("want %d billing lines, have %d", want, have)
+	}
+	if want, have := 1, counts[countedLine{WarnLevel, "db"}]; want != have {
+		t.Errorf("want %d db lines, have %d", want, have)
+	}
+	if want, have := 1, counts[countedLine{level: WarnLevel}]; want != have {
+		t.Errorf("want %d lines without a component, have %d", want, have)
+	}
+}
+
+func TestMetricsLoggerCountsOnlyWrittenLines(t *testing.T) {
+	buf := logWithBuffer()
+	GlobalLogger.(*CoreLogger).SetSampler(NewSampler(1, 0, time.Hour))
+	counts := lineCounts{}
+	logger := NewMetricsLogger(NewDedupeLogger(GlobalLogger, time.Hour, "attempt"), counts)
+
+	logger.LogErrorMessage("failed", "attempt", 1)
+	logger.LogErrorMessage("failed", "attempt", 1)                    // deduplicated
+	logger.With("user_id", 7).LogErrorMessage("failed", "attempt", 2) // sampled out
+
+	if want, have := 1, counts.total(); want != have {
+		t.Errorf("want %d lines, have %d", want, have)
+	}
+	checkLogFormatMatches(t, "attempt=1 msg=failed level=error\n", buf)
+}
@@ -0,0 +1,179 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// netDialTimeout bounds how long a write waits to (re)connect.
+	netDialTimeout = 5 * time.Second
+	// After failing to connect, messages are dropped until the next attempt is due, backing off
+	// exponentially between these intervals.
+	netRedialInitialInterval = 100 * time.Millisecond
+	netRedialMaxInterval     = 10 * time.Second
+)
+
+var (
+	// errNotConnected is returned for messages dropped while a network output waits to reconnect.
+	errNotConnected = errors.New("log: not connected, waiting to reconnect")
+	// errConnClosed is returned for messages written to a closed network output.
+	errConnClosed = errors.New("log: connection closed")
+)
+
+// reconnectingConn writes messages to a network address, connecting on the first write and
+// reconnecting after a failed one, so the server needn't be available when it is created.
+// Connecting happens outside the lock, with messages written meanwhile dropped, so a server that is down
+// doesn't stall every goroutine logging.
+type reconnectingConn struct {
+	network string
+	address string
+
+	mu             sync.Mutex
+	conn           net.Conn
+	dialing        bool
+	redialAt       time.Time
+	redialInterval time.Duration
+	closed         bool
+}
+
+func newReconnectingConn(network, address string) (*reconnectingConn, error) {
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("unsupported network %q", network)
+	}
+	if address == "" {
+		return nil, errors.New("missing address")
+	}
+	return &reconnectingConn{network: network, address: address}, nil
+}
+
+// isStream reports whether the connection is a stream, which needs messages framed, rather than datagrams.
+func (c *reconnectingConn) isStream() bool {
+	switch c.network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return true
+	}
+	return false
+}
+
+// write sends each message in turn, retrying once on a new connection if the write fails.
+func (c *reconnectingConn) write(messages ...[]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if c.conn == nil {
+			if err = c.connectLocked(); err != nil {
+				return err
+			}
+		}
+		if err = c.writeAll(messages); err == nil {
+			return nil
+		}
+		c.conn.Close()
+		c.conn = nil
+	}
+	return err
+}
+
+// connectLocked dials the address, unlocking c.mu meanwhile, unless another write is already
+// connecting or the next attempt isn't due yet.
+func (c *reconnectingConn) connectLocked() error {
+	if c.closed {
+		return errConnClosed
+	}
+	if c.dialing || time.Now().Before(c.redialAt) {
+		return errNotConnected
+	}
+	c.dialing = true
+	c.mu.Unlock()
+	conn, err := net.DialTimeout(c.network, c.address, netDialTimeout)
+	c.mu.Lock()
+	c.dialing = false
+
+	if err != nil {
+		if c.redialInterval *= 2; c.redialInterval == 0 {
+			c.redialInterval = netRedialInitialInterval
+		} else if c.redialInterval > netRedialMaxInterval {
+			c.redialInterval = netRedialMaxInterval
+		}
+		c.redialAt = time.Now().Add(c.redialInterval)
+		return err
+	}
+	if c.closed {
+		conn.Close()
+		return errConnClosed
+	}
+	c.conn, c.redialInterval = conn, 0
+	return nil
+}
+
+func (c *reconnectingConn) writeAll(messages [][]byte) error {
+	for _, message := range messages {
+		if _, err := c.conn.Write(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the connection; later messages are dropped.
+func (c *reconnectingConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// splitLogKeyvals separates the message, level and timestamp written by CoreLogger in format from the other fields.
+// The level is returned as its Level name, e.g. "warn", where format's level value is known,
+// and the timestamp is zero if the line has none.
+func splitLogKeyvals(format Format, keyvals []interface{}) (msg, level string, timestamp time.Time, fields []interface{}) {
+	if len(keyvals)%2 == 1 {
+		keyvals = append(keyvals, nil) // missing a value
+	}
+	fields = make([]interface{}, 0, len(keyvals))
+	for i := 0; i < len(keyvals); i += 2 {
+		switch keyvals[i] {
+		case format.MessageKey:
+			msg = fmt.Sprint(keyvals[i+1])
+		case format.LevelKey:
+			level = format.levelName(fmt.Sprint(keyvals[i+1]))
+		case format.TimestampKey:
+			if t, ok := keyvals[i+1].(time.Time); ok {
+				timestamp = t
+				continue
+			}
+			fields = append(fields, keyvals[i], keyvals[i+1])
+		default:
+			fields = append(fields, keyvals[i], keyvals[i+1])
+		}
+	}
+	return msg, level, timestamp, fields
+}
+
+// syslogSeverities maps levels to RFC 5424 severities.
+var syslogSeverities = map[string]int{
+	"debug": 7,
+	"info":  6,
+	"warn":  4,
+	"error": 3,
+}
+
+func syslogSeverity(level string) int {
+	if severity, ok := syslogSeverities[level]; ok {
+		return severity
+	}
+	return 5 // notice
+}
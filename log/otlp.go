@@ -0,0 +1,619 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrOTLPExporterClosed is returned when logging to a closed OTLPExporter.
+var ErrOTLPExporterClosed = errors.New("otlp log exporter closed")
+
+// OTLPProtocol is the transport an OTLPExporter sends log records with.
+type OTLPProtocol int
+
+const (
+	// OTLPHTTP posts protobuf encoded records to the endpoint's /v1/logs path.
+	OTLPHTTP OTLPProtocol = iota
+	// OTLPGRPC calls the collector's LogsService Export method, over plaintext HTTP/2 for http:// endpoints.
+	// It needs Go 1.24 or later.
+	OTLPGRPC
+)
+
+const (
+	otlpScopeName      = "github.com/intercom/gocore/log"
+	otlpGRPCExportPath = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+)
+
+// otlpSeverities maps levels to OTLP severity numbers.
+var otlpSeverities = map[string]int{
+	"debug": 5,
+	"info":  9,
+	"warn":  13,
+	"error": 17,
+}
+
+// otlpRetryableGRPCCodes are the gRPC status codes the OTLP specification says to retry.
+var otlpRetryableGRPCCodes = map[int]bool{
+	1:  true, // CANCELLED
+	4:  true, // DEADLINE_EXCEEDED
+	8:  true, // RESOURCE_EXHAUSTED
+	10: true, // ABORTED
+	11: true, // OUT_OF_RANGE
+	14: true, // UNAVAILABLE
+	15: true, // DATA_LOSS
+}
+
+// OTLPConfig configures an OTLPExporter. Zero values take the defaults of the OpenTelemetry SDK.
+type OTLPConfig struct {
+	Protocol OTLPProtocol
+	// Endpoint is the collector's base URL; defaults to http://localhost:4318 for HTTP and http://localhost:4317 for gRPC.
+	Endpoint string
+	// Headers are added to each request, e.g. for authentication.
+	Headers map[string]string
+	// Resource attributes describe the service logging; "service.name" defaults to the program name.
+	Resource map[string]string
+	// SpanContext returns the trace and span ids of the span in a context, for TraceFields.
+	// With OpenTelemetry tracing, use trace.SpanContextFromContext.
+	SpanContext func(ctx context.Context) (traceID [16]byte, spanID [8]byte, ok bool)
+
+	// QueueSize is the number of records buffered for export, beyond which records are dropped; defaults to 2048.
+	// As many again may be waiting to be retried.
+	QueueSize int
+	// BatchSize is the most records sent in one request; defaults to 512.
+	BatchSize int
+	// BatchTimeout is the longest a record waits for a batch to fill; defaults to 1s.
+	BatchTimeout time.Duration
+	// Timeout bounds each request, and Close as a whole, including retrying failed requests; defaults to 10s.
+	Timeout time.Duration
+	// Failed requests that may succeed later are retried with exponential backoff from RetryInitialInterval,
+	// defaulting to 5s, up to RetryMaxInterval, 30s, until RetryMaxElapsed, 1m, has passed.
+	// Records keep being batched and sent while earlier batches wait to be retried.
+	RetryInitialInterval time.Duration
+	RetryMaxInterval     time.Duration
+	RetryMaxElapsed      time.Duration
+
+	// Client sends requests, defaulting to one suited to the protocol.
+	Client *http.Client
+}
+
+// OTLPExporter is a go-kit logger sending lines as OpenTelemetry log records to a collector,
+// in batches from a background goroutine. Lines are logged with their level as the severity,
+// msg as the body and other fields, including standard fields, as attributes;
+// "trace_id" and "span_id" fields, as added by TraceFields, set the record's trace context.
+type OTLPExporter struct {
+	dropped uint64 // accessed atomically, first for alignment
+
+	config   OTLPConfig
+	url      string
+	client   *http.Client
+	resource []interface{}
+	// ctx bounds requests, along with Timeout; cancel ends it at the Close deadline.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	records chan otlpRecord
+	flushes chan chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+	// closeDeadline is when Close gives up exporting, set before stop is closed.
+	closeDeadline time.Time
+
+	// batches waiting to be retried, only accessed by the run goroutine
+	retries      []*otlpPendingBatch
+	retryRecords int
+}
+
+// otlpPendingBatch is an encoded batch of records waiting to be retried.
+type otlpPendingBatch struct {
+	body     []byte
+	records  int
+	next     time.Time     // when to retry
+	interval time.Duration // backoff after the next failure
+	deadline time.Time     // when to give up
+}
+
+type otlpRecord struct {
+	time         time.Time
+	severity     int
+	severityText string
+	body         string
+	attributes   []interface{}
+	traceID      []byte
+	spanID       []byte
+}
+
+// otlpRetryableError is a failed export which may succeed if retried, after the server's requested delay if set.
+type otlpRetryableError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *otlpRetryableError) Error() string {
+	return e.err.Error()
+}
+
+// OTLPLoggerTo returns a logger exporting to an OpenTelemetry collector.
+// Close the returned exporter on shutdown to send buffered records.
+func OTLPLoggerTo(config OTLPConfig) (*CoreLogger, *OTLPExporter, error) {
+	exporter, err := NewOTLPExporter(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewCoreLogger(exporter), exporter, nil
+}
+
+// NewOTLPExporter returns an OTLPExporter sending records to the configured collector.
+func NewOTLPExporter(config OTLPConfig) (*OTLPExporter, error) {
+	config = config.withDefaults()
+	e := &OTLPExporter{
+		config:   config,
+		client:   config.Client,
+		resource: otlpResource(config.Resource),
+		records:  make(chan otlpRecord, config.QueueSize),
+		flushes:  make(chan chan struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	switch config.Protocol {
+	case OTLPHTTP:
+		e.url = strings.TrimSuffix(config.Endpoint, "/") + "/v1/logs"
+		if e.client == nil {
+			e.client = &http.Client{}
+		}
+	case OTLPGRPC:
+		e.url = strings.TrimSuffix(config.Endpoint, "/") + otlpGRPCExportPath
+		if e.client == nil {
+			transport, err := otlpGRPCTransport()
+			if err != nil {
+				return nil, err
+			}
+			e.client = &http.Client{Transport: transport}
+		}
+	default:
+		return nil, fmt.Errorf("unknown otlp protocol %d", config.Protocol)
+	}
+	go e.run()
+	return e, nil
+}
+
+func (c OTLPConfig) withDefaults() OTLPConfig {
+	if c.Endpoint == "" {
+		c.Endpoint = "http://localhost:4318"
+		if c.Protocol == OTLPGRPC {
+			c.Endpoint = "http://localhost:4317"
+		}
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 2048
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 512
+	}
+	if c.BatchTimeout <= 0 {
+		c.BatchTimeout = time.Second
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.RetryInitialInterval <= 0 {
+		c.RetryInitialInterval = 5 * time.Second
+	}
+	if c.RetryMaxInterval <= 0 {
+		c.RetryMaxInterval = 30 * time.Second
+	}
+	if c.RetryMaxElapsed <= 0 {
+		c.RetryMaxElapsed = time.Minute
+	}
+	return c
+}
+
+// otlpResource returns the resource attributes as sorted keyvals, with a default service.name.
+func otlpResource(attributes map[string]string) []interface{} {
+	keys := make([]string, 0, len(attributes)+1)
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	if _, ok := attributes["service.name"]; !ok {
+		keys = append(keys, "service.name")
+	}
+	sort.Strings(keys)
+
+	resource := make([]interface{}, 0, len(keys)*2)
+	for _, key := range keys {
+		value, ok := attributes[key]
+		if !ok {
+			value = filepath.Base(os.Args[0])
+		}
+		resource = append(resource, key, value)
+	}
+	return resource
+}
+
+// TraceFields returns "trace_id" and "span_id" fields for the span in ctx, found with OTLPConfig.SpanContext,
+// to add to a logger with With. It returns none if there is no span.
+func (e *OTLPExporter) TraceFields(ctx context.Context) []interface{} {
+	if e.config.SpanContext == nil {
+		return nil
+	}
+	traceID, spanID, ok := e.config.SpanContext(ctx)
+	if !ok {
+		return nil
+	}
+	return []interface{}{"trace_id", hex.EncodeToString(traceID[:]), "span_id", hex.EncodeToString(spanID[:])}
+}
+
+func (e *OTLPExporter) withFormat(format Format) formattedOutput {
+	return &otlpOutput{exporter: e, format: format}
+}
+
+// otlpOutput is an OTLPExporter reading lines written in a Format other than DefaultFormat.
+type otlpOutput struct {
+	exporter *OTLPExporter
+	format   Format
+}
+
+func (o *otlpOutput) Log(keyvals ...interface{}) error {
+	return o.exporter.log(o.format, keyvals)
+}
+
+func (o *otlpOutput) withFormat(format Format) formattedOutput {
+	return o.exporter.withFormat(format)
+}
+
+// Log queues a record for export, dropping it if the queue is full.
+func (e *OTLPExporter) Log(keyvals ...interface{}) error {
+	return e.log(DefaultFormat, keyvals)
+}
+
+func (e *OTLPExporter) log(format Format, keyvals []interface{}) error {
+	record := newOTLPRecord(time.Now(), format, keyvals)
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return ErrOTLPExporterClosed
+	}
+	select {
+	case e.records <- record:
+	default:
+		atomic.AddUint64(&e.dropped, 1)
+	}
+	return nil
+}
+
+// Dropped returns the number of records dropped because the queue was full or they failed to export.
+func (e *OTLPExporter) Dropped() uint64 {
+	return atomic.LoadUint64(&e.dropped)
+}
+
+// Flush exports all queued records, returning once each has been sent or has failed once;
+// those failing with errors that may succeed later are retried in the background.
+func (e *OTLPExporter) Flush() {
+	flushed := make(chan struct{})
+	select {
+	case e.flushes <- flushed:
+		<-flushed
+	case <-e.done:
+	}
+}
+
+// Close exports queued records and stops the exporter,
+// taking up to OTLPConfig.Timeout in all to export and retry failures before dropping them.
+func (e *OTLPExporter) Close() error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	e.closeDeadline = time.Now().Add(e.config.Timeout)
+	timer := time.AfterFunc(e.config.Timeout, e.cancel)
+	close(e.stop)
+	e.mu.Unlock()
+
+	<-e.done
+	timer.Stop()
+	e.cancel()
+	return nil
+}
+
+func (e *OTLPExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.config.BatchTimeout)
+	defer ticker.Stop()
+
+	batch := make([]otlpRecord, 0, e.config.BatchSize)
+	for {
+		select {
+		case record := <-e.records:
+			batch = append(batch, record)
+			if len(batch) >= e.config.BatchSize {
+				e.export(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			e.export(batch)
+			batch = batch[:0]
+			e.retryDue(time.Now())
+		case flushed := <-e.flushes:
+			e.exportQueued(batch)
+			batch = batch[:0]
+			close(flushed)
+		case <-e.stop:
+			e.exportQueued(batch)
+			e.retryUntil(e.closeDeadline)
+			return
+		}
+	}
+}
+
+// exportQueued exports batch along with every record waiting in the queue.
+func (e *OTLPExporter) exportQueued(batch []otlpRecord) {
+	for {
+		select {
+		case record := <-e.records:
+			batch = append(batch, record)
+			if len(batch) >= e.config.BatchSize {
+				e.export(batch)
+				batch = batch[:0]
+			}
+		default:
+			e.export(batch)
+			return
+		}
+	}
+}
+
+// export sends a batch, scheduling a retry if it fails with an error that may succeed later.
+func (e *OTLPExporter) export(batch []otlpRecord) {
+	if len(batch) == 0 {
+		return
+	}
+	e.attempt(&otlpPendingBatch{
+		body:     encodeOTLPRequest(e.resource, otlpScopeName, batch),
+		records:  len(batch),
+		interval: e.config.RetryInitialInterval,
+		deadline: time.Now().Add(e.config.RetryMaxElapsed),
+	})
+}
+
+// attempt sends a batch once, scheduling a retry or dropping it if that fails.
+func (e *OTLPExporter) attempt(batch *otlpPendingBatch) {
+	err := e.send(batch.body)
+	if err == nil {
+		return
+	}
+	if retryable, ok := err.(*otlpRetryableError); ok {
+		wait := batch.interval
+		if retryable.after > 0 {
+			wait = retryable.after
+		}
+		batch.next = time.Now().Add(wait)
+		if batch.interval *= 2; batch.interval > e.config.RetryMaxInterval {
+			batch.interval = e.config.RetryMaxInterval
+		}
+		if !batch.next.After(batch.deadline) {
+			e.scheduleRetry(batch)
+			return
+		}
+	}
+	atomic.AddUint64(&e.dropped, uint64(batch.records))
+}
+
+// scheduleRetry keeps a batch to retry, dropping it if too many records are already waiting.
+func (e *OTLPExporter) scheduleRetry(batch *otlpPendingBatch) {
+	if e.retryRecords+batch.records > e.config.QueueSize {
+		atomic.AddUint64(&e.dropped, uint64(batch.records))
+		return
+	}
+	e.retries = append(e.retries, batch)
+	e.retryRecords += batch.records
+}
+
+// retryDue retries the batches due by now.
+func (e *OTLPExporter) retryDue(now time.Time) {
+	retries := e.retries
+	e.retries, e.retryRecords = nil, 0
+	for _, batch := range retries {
+		if batch.next.After(now) {
+			e.scheduleRetry(batch)
+			continue
+		}
+		e.attempt(batch)
+	}
+}
+
+// retryUntil retries batches as they fall due until none are left, dropping those not due by deadline.
+func (e *OTLPExporter) retryUntil(deadline time.Time) {
+	for len(e.retries) > 0 {
+		next := e.retries[0].next
+		for _, batch := range e.retries[1:] {
+			if batch.next.Before(next) {
+				next = batch.next
+			}
+		}
+		if next.After(deadline) {
+			atomic.AddUint64(&e.dropped, uint64(e.retryRecords))
+			e.retries, e.retryRecords = nil, 0
+			return
+		}
+		time.Sleep(next.Sub(time.Now()))
+		e.retryDue(time.Now())
+	}
+}
+
+func (e *OTLPExporter) send(body []byte) error {
+	if e.config.Protocol == OTLPGRPC {
+		return e.sendGRPC(body)
+	}
+	return e.sendHTTP(body)
+}
+
+func (e *OTLPExporter) sendHTTP(body []byte) error {
+	resp, err := e.post(body, "application/x-protobuf")
+	if err != nil {
+		return &otlpRetryableError{err: err}
+	}
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable, resp.StatusCode == http.StatusGatewayTimeout:
+		return &otlpRetryableError{
+			err:   fmt.Errorf("otlp export failed: %s", resp.Status),
+			after: retryAfter(resp.Header),
+		}
+	}
+	return fmt.Errorf("otlp export failed: %s", resp.Status)
+}
+
+func (e *OTLPExporter) sendGRPC(body []byte) error {
+	// a gRPC message is a compressed flag and big endian length before the protobuf
+	frame := make([]byte, 5+len(body))
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(body)))
+	copy(frame[5:], body)
+
+	resp, err := e.post(frame, "application/grpc")
+	if err != nil {
+		return &otlpRetryableError{err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &otlpRetryableError{err: fmt.Errorf("otlp export failed: %s", resp.Status)}
+	}
+	status := resp.Trailer.Get("Grpc-Status")
+	if status == "" {
+		status = resp.Header.Get("Grpc-Status") // trailers only response
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return fmt.Errorf("otlp export failed: invalid grpc status %q", status)
+	}
+	if code == 0 {
+		return nil
+	}
+	message := resp.Trailer.Get("Grpc-Message")
+	if message == "" {
+		message = resp.Header.Get("Grpc-Message")
+	}
+	err = fmt.Errorf("otlp export failed: grpc status %d: %s", code, message)
+	if otlpRetryableGRPCCodes[code] {
+		return &otlpRetryableError{err: err}
+	}
+	return err
+}
+
+// post sends a request, returning the response with its body and trailers read.
+func (e *OTLPExporter) post(body []byte, contentType string) (*http.Response, error) {
+	req, err := http.NewRequest("POST", e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(e.ctx, e.config.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", contentType)
+	if contentType == "application/grpc" {
+		req.Header.Set("TE", "trailers")
+	}
+	for key, value := range e.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// retryAfter returns the delay in a Retry-After header given in seconds, or zero.
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// newOTLPRecord returns a record for a line, with its timestamp, or else now.
+func newOTLPRecord(now time.Time, format Format, keyvals []interface{}) otlpRecord {
+	msg, level, t, fields := splitLogKeyvals(format, keyvals)
+	if t.IsZero() {
+		t = now
+	}
+	record := otlpRecord{
+		time:         t,
+		severity:     otlpSeverities[level],
+		severityText: level,
+		body:         msg,
+		attributes:   make([]interface{}, 0, len(fields)),
+	}
+	for i := 0; i < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		switch key {
+		case "trace_id":
+			if id, err := hex.DecodeString(fmt.Sprint(fields[i+1])); err == nil && len(id) == 16 {
+				record.traceID = id
+				continue
+			}
+		case "span_id":
+			if id, err := hex.DecodeString(fmt.Sprint(fields[i+1])); err == nil && len(id) == 8 {
+				record.spanID = id
+				continue
+			}
+		}
+		record.attributes = append(record.attributes, key, otlpValue(fields[i+1]))
+	}
+	return record
+}
+
+// otlpValue converts a field value to one of the types an OTLP attribute can hold.
+func otlpValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string, bool, int64, float64:
+		return v
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case float32:
+		return float64(v)
+	}
+	return fmt.Sprint(value)
+}
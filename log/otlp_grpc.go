@@ -0,0 +1,14 @@
+//go:build go1.24
+// +build go1.24
+
+package log
+
+import "net/http"
+
+// otlpGRPCTransport returns a transport speaking HTTP/2 only, without TLS for http:// endpoints as gRPC servers expect.
+func otlpGRPCTransport() (http.RoundTripper, error) {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+	return &http.Transport{Protocols: protocols}, nil
+}
@@ -0,0 +1,14 @@
+//go:build !go1.24
+// +build !go1.24
+
+package log
+
+import (
+	"errors"
+	"net/http"
+)
+
+// otlpGRPCTransport needs http.Protocols, added in Go 1.24, for plaintext HTTP/2.
+func otlpGRPCTransport() (http.RoundTripper, error) {
+	return nil, errors.New("otlp over grpc needs go 1.24 or later, or a Client with an HTTP/2 transport")
+}
@@ -0,0 +1,78 @@
+//go:build go1.24
+// +build go1.24
+
+package log
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestOTLPLoggerExportsOverGRPC(t *testing.T) {
+	collector := newOTLPGRPCCollector(t, nil)
+	defer collector.Close()
+
+	logger, exporter, err := OTLPLoggerTo(OTLPConfig{Protocol: OTLPGRPC, Endpoint: collector.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.LogInfoMessage("started")
+	exporter.Close()
+
+	requests := collector.Requests()
+	if want, have := 1, len(requests); want != have {
+		t.Fatalf("want %d requests, have %d", want, have)
+	}
+	if want, have := "/opentelemetry.proto.collector.logs.v1.LogsService/Export", requests[0].path; want != have {
+		t.Errorf("want path %s, have %s", want, have)
+	}
+	records := requests[0].records(t)
+	if want, have := 1, len(records); want != have {
+		t.Fatalf("want %d records, have %d", want, have)
+	}
+	if want, have := uint64(9), records[0][2][0]; want != have {
+		t.Errorf("want severity %v, have %v", want, have)
+	}
+	if want, have := uint64(0), exporter.Dropped(); want != have {
+		t.Errorf("want %d dropped, have %d", want, have)
+	}
+}
+
+func TestOTLPExporterRetriesUnavailableGRPCStatus(t *testing.T) {
+	failures := 1
+	collector := newOTLPGRPCCollector(t, func(w http.ResponseWriter) bool {
+		if failures > 0 {
+			failures--
+			w.Header().Set("Content-Type", "application/grpc")
+			w.Header().Set("Grpc-Status", "14") // UNAVAILABLE, trailers only
+			w.WriteHeader(http.StatusOK)
+			return false
+		}
+		return true
+	})
+	defer collector.Close()
+
+	logger, exporter, err := OTLPLoggerTo(OTLPConfig{Protocol: OTLPGRPC, Endpoint: collector.URL, RetryInitialInterval: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.LogErrorMessage("boom")
+	exporter.Close()
+
+	if want, have := 1, len(collector.Requests()); want != have {
+		t.Errorf("want %d accepted requests, have %d", want, have)
+	}
+	if want, have := uint64(0), exporter.Dropped(); want != have {
+		t.Errorf("want %d dropped, have %d", want, have)
+	}
+}
+
+// newOTLPGRPCCollector returns a started collector accepting plaintext HTTP/2, as gRPC servers do.
+func newOTLPGRPCCollector(t *testing.T, accept func(w http.ResponseWriter) bool) *otlpCollector {
+	c := newUnstartedOTLPCollector(t, accept)
+	c.Config.Protocols = new(http.Protocols)
+	c.Config.Protocols.SetHTTP1(true)
+	c.Config.Protocols.SetUnencryptedHTTP2(true)
+	c.Start()
+	return c
+}
@@ -0,0 +1,137 @@
+package log
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Protobuf encoding of the OTLP logs messages, opentelemetry/proto/collector/logs/v1/logs_service.proto,
+// written by hand to avoid depending on the generated code and the protobuf runtime.
+
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+)
+
+// protoBuffer appends protobuf encoded fields.
+type protoBuffer []byte
+
+func (b *protoBuffer) varint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	*b = append(*b, buf[:n]...)
+}
+
+func (b *protoBuffer) tag(field int, wireType int) {
+	b.varint(uint64(field<<3 | wireType))
+}
+
+func (b *protoBuffer) uint64Field(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.tag(field, protoVarint)
+	b.varint(v)
+}
+
+func (b *protoBuffer) fixed64Field(field int, v uint64) {
+	b.tag(field, protoFixed64)
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	*b = append(*b, buf[:]...)
+}
+
+func (b *protoBuffer) bytesField(field int, v []byte) {
+	b.tag(field, protoBytes)
+	b.varint(uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+func (b *protoBuffer) stringField(field int, v string) {
+	if v == "" {
+		return
+	}
+	b.tag(field, protoBytes)
+	b.varint(uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+// messageField appends an embedded message, encoded by encode.
+func (b *protoBuffer) messageField(field int, encode func(*protoBuffer)) {
+	var m protoBuffer
+	encode(&m)
+	b.bytesField(field, m)
+}
+
+// encodeOTLPRequest encodes an ExportLogsServiceRequest with the records from a single resource and scope.
+func encodeOTLPRequest(resource []interface{}, scope string, records []otlpRecord) []byte {
+	var b protoBuffer
+	b.messageField(1, func(resourceLogs *protoBuffer) { // ExportLogsServiceRequest.resource_logs
+		resourceLogs.messageField(1, func(r *protoBuffer) { // ResourceLogs.resource
+			encodeOTLPAttributes(r, 1, resource) // Resource.attributes
+		})
+		resourceLogs.messageField(2, func(scopeLogs *protoBuffer) { // ResourceLogs.scope_logs
+			scopeLogs.messageField(1, func(s *protoBuffer) { // ScopeLogs.scope
+				s.stringField(1, scope) // InstrumentationScope.name
+			})
+			for _, record := range records {
+				scopeLogs.messageField(2, record.encode) // ScopeLogs.log_records
+			}
+		})
+	})
+	return b
+}
+
+// encode appends the fields of a LogRecord.
+func (r otlpRecord) encode(b *protoBuffer) {
+	b.fixed64Field(1, uint64(r.time.UnixNano())) // time_unix_nano
+	b.uint64Field(2, uint64(r.severity))         // severity_number
+	b.stringField(3, r.severityText)             // severity_text
+	b.messageField(5, func(body *protoBuffer) {  // body
+		encodeOTLPValue(body, r.body)
+	})
+	encodeOTLPAttributes(b, 6, r.attributes) // attributes
+	if len(r.traceID) > 0 {
+		b.bytesField(9, r.traceID) // trace_id
+	}
+	if len(r.spanID) > 0 {
+		b.bytesField(10, r.spanID) // span_id
+	}
+	b.fixed64Field(11, uint64(r.time.UnixNano())) // observed_time_unix_nano
+}
+
+// encodeOTLPAttributes appends keyvals as repeated KeyValue fields.
+func encodeOTLPAttributes(b *protoBuffer, field int, keyvals []interface{}) {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		key, value := keyvals[i].(string), keyvals[i+1]
+		b.messageField(field, func(kv *protoBuffer) {
+			kv.stringField(1, key)                    // KeyValue.key
+			kv.messageField(2, func(v *protoBuffer) { // KeyValue.value
+				encodeOTLPValue(v, value)
+			})
+		})
+	}
+}
+
+// encodeOTLPValue appends the fields of an AnyValue.
+func encodeOTLPValue(b *protoBuffer, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		b.tag(1, protoBytes) // string_value, written even if empty to set the oneof
+		b.varint(uint64(len(v)))
+		*b = append(*b, v...)
+	case bool:
+		b.tag(2, protoVarint) // bool_value
+		if v {
+			b.varint(1)
+		} else {
+			b.varint(0)
+		}
+	case int64:
+		b.tag(3, protoVarint) // int_value
+		b.varint(uint64(v))
+	case float64:
+		b.fixed64Field(4, math.Float64bits(v)) // double_value
+	}
+}
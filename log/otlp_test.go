@@ -0,0 +1,390 @@
+package log
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOTLPLoggerExportsOverHTTP(t *testing.T) {
+	collector := newOTLPCollector(t, nil)
+	defer collector.Close()
+
+	logger, exporter, err := OTLPLoggerTo(OTLPConfig{
+		Endpoint: collector.URL,
+		Headers:  map[string]string{"Authorization": "Bearer abc"},
+		Resource: map[string]string{"service.name": "billing"},
+		SpanContext: func(ctx context.Context) ([16]byte, [8]byte, bool) {
+			return [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, [8]byte{1, 2, 3, 4, 5, 6, 7, 8}, true
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exporter.Close()
+
+	logger.With(exporter.TraceFields(context.Background())...).With("region", "eu").LogWarnMessage("card declined", "attempt", 2)
+	exporter.Flush()
+
+	requests := collector.Requests()
+	if want, have := 1, len(requests); want != have {
+		t.Fatalf("want %d requests, have %d", want, have)
+	}
+	request := requests[0]
+	if want, have := "/v1/logs", request.path; want != have {
+		t.Errorf("want path %s, have %s", want, have)
+	}
+	if want, have := "application/x-protobuf", request.header.Get("Content-Type"); want != have {
+		t.Errorf("want content type %s, have %s", want, have)
+	}
+	if want, have := "Bearer abc", request.header.Get("Authorization"); want != have {
+		t.Errorf("want authorization %s, have %s", want, have)
+	}
+
+	resource := otlpAttributes(t, decodeProto(t, request.resourceLogs()[0][1][0].([]byte))[1])
+	if want, have := "billing", resource["service.name"]; want != have {
+		t.Errorf("want service.name %v, have %v", want, have)
+	}
+	records := request.records(t)
+	if want, have := 1, len(records); want != have {
+		t.Fatalf("want %d records, have %d", want, have)
+	}
+	record := records[0]
+	if want, have := uint64(13), record[2][0]; want != have {
+		t.Errorf("want severity %v, have %v", want, have)
+	}
+	if want, have := "warn", string(record[3][0].([]byte)); want != have {
+		t.Errorf("want severity text %v, have %v", want, have)
+	}
+	if want, have := "card declined", string(decodeProto(t, record[5][0].([]byte))[1][0].([]byte)); want != have {
+		t.Errorf("want body %v, have %v", want, have)
+	}
+	attributes := otlpAttributes(t, record[6])
+	if want, have := "eu", attributes["region"]; want != have {
+		t.Errorf("want region %v, have %v", want, have)
+	}
+	if want, have := uint64(2), attributes["attempt"]; want != have {
+		t.Errorf("want attempt %v, have %v", want, have)
+	}
+	if _, ok := attributes["trace_id"]; ok {
+		t.Errorf("want trace_id as a record field, have it as an attribute")
+	}
+	if want, have := 16, len(record[9][0].([]byte)); want != have {
+		t.Errorf("want %d byte trace id, have %d", want, have)
+	}
+	if want, have := 8, len(record[10][0].([]byte)); want != have {
+		t.Errorf("want %d byte span id, have %d", want, have)
+	}
+}
+
+func TestOTLPExporterBatches(t *testing.T) {
+	collector := newOTLPCollector(t, nil)
+	defer collector.Close()
+
+	logger, exporter, err := OTLPLoggerTo(OTLPConfig{Endpoint: collector.URL, BatchSize: 2, BatchTimeout: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		logger.LogInfo("i", i)
+	}
+	exporter.Close()
+
+	requests := collector.Requests()
+	if want, have := 3, len(requests); want != have {
+		t.Fatalf("want %d requests, have %d", want, have)
+	}
+	total := 0
+	for _, request := range requests {
+		records := request.records(t)
+		if len(records) > 2 {
+			t.Errorf("want batches of at most 2 records, have %d", len(records))
+		}
+		total += len(records)
+	}
+	if want, have := 5, total; want != have {
+		t.Errorf("want %d records, have %d", want, have)
+	}
+	if err := exporter.Log("msg", "late"); err != ErrOTLPExporterClosed {
+		t.Errorf("want %v, have %v", ErrOTLPExporterClosed, err)
+	}
+}
+
+func TestOTLPExporterRetries(t *testing.T) {
+	failures := 2
+	collector := newOTLPCollector(t, func(w http.ResponseWriter) bool {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return false
+		}
+		return true
+	})
+	defer collector.Close()
+
+	logger, exporter, err := OTLPLoggerTo(OTLPConfig{Endpoint: collector.URL, RetryInitialInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.LogErrorMessage("boom")
+	exporter.Close()
+
+	if want, have := 1, len(collector.Requests()); want != have {
+		t.Errorf("want %d accepted requests, have %d", want, have)
+	}
+	if want, have := uint64(0), exporter.Dropped(); want != have {
+		t.Errorf("want %d dropped, have %d", want, have)
+	}
+}
+
+func TestOTLPExporterKeepsExportingWhileRetrying(t *testing.T) {
+	failures := 1
+	collector := newOTLPCollector(t, func(w http.ResponseWriter) bool {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return false
+		}
+		return true
+	})
+	defer collector.Close()
+
+	logger, exporter, err := OTLPLoggerTo(OTLPConfig{Endpoint: collector.URL, RetryInitialInterval: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.LogErrorMessage("first")
+	exporter.Flush() // fails, to be retried
+	logger.LogErrorMessage("second")
+	exporter.Flush()
+
+	if want, have := 1, len(collector.Requests()); want != have {
+		t.Errorf("want %d accepted requests before the retry, have %d", want, have)
+	}
+	exporter.Close()
+	if want, have := 2, len(collector.Requests()); want != have {
+		t.Errorf("want %d accepted requests after the retry, have %d", want, have)
+	}
+	if want, have := uint64(0), exporter.Dropped(); want != have {
+		t.Errorf("want %d dropped, have %d", want, have)
+	}
+}
+
+func TestOTLPExporterCloseStopsRetrying(t *testing.T) {
+	collector := newOTLPCollector(t, func(w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return false
+	})
+	defer collector.Close()
+
+	logger, exporter, err := OTLPLoggerTo(OTLPConfig{Endpoint: collector.URL, Timeout: 100 * time.Millisecond, RetryInitialInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.LogErrorMessage("boom")
+	start := time.Now()
+	exporter.Close()
+
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("want Close to give up retrying after its timeout, took %v", took)
+	}
+	if want, have := uint64(1), exporter.Dropped(); want != have {
+		t.Errorf("want %d dropped, have %d", want, have)
+	}
+}
+
+func TestOTLPExporterCloseBoundsExportsByTimeout(t *testing.T) {
+	release := make(chan struct{})
+	collector := newOTLPCollector(t, func(w http.ResponseWriter) bool {
+		<-release
+		return true
+	})
+	defer collector.Close()
+	defer close(release)
+
+	logger, exporter, err := OTLPLoggerTo(OTLPConfig{Endpoint: collector.URL, BatchSize: 1, Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		logger.LogErrorMessage("boom", "attempt", i)
+	}
+	start := time.Now()
+	exporter.Close()
+
+	if took := time.Since(start); took > 400*time.Millisecond {
+		t.Errorf("want Close to take about its timeout in all, took %v", took)
+	}
+}
+
+func TestOTLPRecordUsesLineTimestamp(t *testing.T) {
+	now := time.Now()
+	logged := time.Date(2017, 6, 1, 10, 0, 0, 250000123, time.UTC)
+	record := newOTLPRecord(now, DatadogFormat, []interface{}{"message", "hello", "timestamp", logged, "status", "info"})
+	if want, have := logged, record.time; !want.Equal(have) {
+		t.Errorf("want time %v, have %v", want, have)
+	}
+	if want, have := "hello", record.body; want != have {
+		t.Errorf("want body %q, have %q", want, have)
+	}
+
+	record = newOTLPRecord(now, DefaultFormat, []interface{}{"msg", "hello", "level", "info"})
+	if want, have := now, record.time; !want.Equal(have) {
+		t.Errorf("want time %v without a timestamp, have %v", want, have)
+	}
+}
+
+func TestOTLPExporterDropsOnPermanentFailure(t *testing.T) {
+	collector := newOTLPCollector(t, func(w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusBadRequest)
+		return false
+	})
+	defer collector.Close()
+
+	logger, exporter, err := OTLPLoggerTo(OTLPConfig{Endpoint: collector.URL, RetryInitialInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.LogErrorMessage("boom")
+	logger.LogErrorMessage("boom")
+	exporter.Close()
+
+	if want, have := uint64(2), exporter.Dropped(); want != have {
+		t.Errorf("want %d dropped, have %d", want, have)
+	}
+}
+
+// otlpCollector is a collector stand-in recording the requests it accepts.
+type otlpCollector struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []otlpRequest
+}
+
+type otlpRequest struct {
+	path   string
+	header http.Header
+	body   map[int][]interface{}
+}
+
+// newOTLPCollector returns a started collector, which accepts requests if accept is nil or returns true.
+func newOTLPCollector(t *testing.T, accept func(w http.ResponseWriter) bool) *otlpCollector {
+	c := newUnstartedOTLPCollector(t, accept)
+	c.Start()
+	return c
+}
+
+// newUnstartedOTLPCollector returns a collector to configure before starting.
+// gRPC requests are unframed and answered with an OK status.
+func newUnstartedOTLPCollector(t *testing.T, accept func(w http.ResponseWriter) bool) *otlpCollector {
+	c := &otlpCollector{}
+	c.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if accept != nil && !accept(w) {
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if r.Header.Get("Content-Type") == "application/grpc" {
+			if r.ProtoMajor != 2 {
+				t.Errorf("want grpc over HTTP/2, have %s", r.Proto)
+			}
+			if len(body) < 5 || int(binary.BigEndian.Uint32(body[1:5])) != len(body)-5 {
+				t.Errorf("invalid grpc frame %q", body)
+				return
+			}
+			body = body[5:]
+			w.Header().Set("Content-Type", "application/grpc")
+			w.WriteHeader(http.StatusOK)
+			w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.requests = append(c.requests, otlpRequest{path: r.URL.Path, header: r.Header, body: decodeProto(t, body)})
+	}))
+	return c
+}
+
+func (c *otlpCollector) Requests() []otlpRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]otlpRequest{}, c.requests...)
+}
+
+// resourceLogs returns the decoded ResourceLogs of the request.
+func (r otlpRequest) resourceLogs() []map[int][]interface{} {
+	var resourceLogs []map[int][]interface{}
+	for _, field := range r.body[1] {
+		resourceLogs = append(resourceLogs, decodeProto(nil, field.([]byte)))
+	}
+	return resourceLogs
+}
+
+// records returns the decoded LogRecords of the request.
+func (r otlpRequest) records(t *testing.T) []map[int][]interface{} {
+	var records []map[int][]interface{}
+	for _, resourceLogs := range r.resourceLogs() {
+		for _, scopeLogs := range resourceLogs[2] {
+			for _, record := range decodeProto(t, scopeLogs.([]byte))[2] {
+				records = append(records, decodeProto(t, record.([]byte)))
+			}
+		}
+	}
+	return records
+}
+
+// otlpAttributes decodes repeated KeyValue fields, with string values as strings and int values as uint64.
+func otlpAttributes(t *testing.T, fields []interface{}) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	for _, field := range fields {
+		kv := decodeProto(t, field.([]byte))
+		value := decodeProto(t, kv[2][0].([]byte))
+		key := string(kv[1][0].([]byte))
+		switch {
+		case len(value[1]) > 0:
+			attributes[key] = string(value[1][0].([]byte))
+		case len(value[3]) > 0:
+			attributes[key] = value[3][0]
+		}
+	}
+	return attributes
+}
+
+// decodeProto decodes a protobuf message into its fields' values: uint64 for varint and fixed64
+// fields and []byte for length delimited ones.
+func decodeProto(t *testing.T, b []byte) map[int][]interface{} {
+	fields := map[int][]interface{}{}
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		b = b[n:]
+		field := int(tag >> 3)
+		switch tag & 7 {
+		case protoVarint:
+			v, n := binary.Uvarint(b)
+			fields[field] = append(fields[field], v)
+			b = b[n:]
+		case protoFixed64:
+			fields[field] = append(fields[field], binary.LittleEndian.Uint64(b))
+			b = b[8:]
+		case protoBytes:
+			length, n := binary.Uvarint(b)
+			b = b[n:]
+			fields[field] = append(fields[field], b[:length])
+			b = b[length:]
+		default:
+			if t != nil {
+				t.Fatalf("unexpected wire type %d", tag&7)
+			}
+			return fields
+		}
+	}
+	return fields
+}
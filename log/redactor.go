@@ -0,0 +1,95 @@
+package log
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces redacted values in log output.
+const RedactedValue = "[REDACTED]"
+
+var (
+	// BearerTokenPattern matches bearer tokens, such as in an Authorization header.
+	BearerTokenPattern = regexp.MustCompile(`(?i)bearer\s+[a-z0-9\-._~+/]+=*`)
+	// EmailPattern matches email addresses.
+	EmailPattern = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+)
+
+// Redactor masks sensitive data in log keyvals. Values of keys matching one of its key patterns are
+// replaced entirely; string values are scrubbed of anything matching one of its value patterns.
+type Redactor struct {
+	keys     []string
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor masking values for the given key names.
+// Key names are matched case-insensitively and may be glob patterns, e.g. "*token*" or "authorization".
+// Like regexp.MustCompile, it panics if a pattern is malformed, rather than redacting nothing.
+func NewRedactor(keys ...string) *Redactor {
+	r := &Redactor{}
+	for _, key := range keys {
+		if _, err := path.Match(key, ""); err != nil {
+			panic(fmt.Sprintf("log: NewRedactor(%q): %v", key, err))
+		}
+		r.keys = append(r.keys, strings.ToLower(key))
+	}
+	return r
+}
+
+// ScrubValues adds patterns to scrub from string values, e.g. BearerTokenPattern or EmailPattern.
+func (r *Redactor) ScrubValues(patterns ...*regexp.Regexp) *Redactor {
+	r.patterns = append(r.patterns, patterns...)
+	return r
+}
+
+// Redact returns a copy of keyvals with sensitive values masked.
+func (r *Redactor) Redact(keyvals []interface{}) []interface{} {
+	redacted := make([]interface{}, len(keyvals))
+	copy(redacted, keyvals)
+	for i := 0; i+1 < len(redacted); i += 2 {
+		if r.matchesKey(fmt.Sprint(redacted[i])) {
+			redacted[i+1] = RedactedValue
+		} else {
+			redacted[i+1] = r.scrub(redacted[i+1])
+		}
+	}
+	return redacted
+}
+
+func (r *Redactor) matchesKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range r.keys {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// scrub masks pattern matches in strings, errors and fmt.Stringers, leaving other values untouched.
+func (r *Redactor) scrub(value interface{}) interface{} {
+	if len(r.patterns) == 0 {
+		return value
+	}
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		return value
+	}
+	scrubbed := s
+	for _, pattern := range r.patterns {
+		scrubbed = pattern.ReplaceAllString(scrubbed, RedactedValue)
+	}
+	if scrubbed == s {
+		return value
+	}
+	return scrubbed
+}
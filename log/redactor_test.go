@@ -0,0 +1,47 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestRedactorMasksKeys(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	logger.SetRedactor(NewRedactor("Authorization", "*token*"))
+
+	logger.LogInfoMessage("request", "authorization", "Basic abc", "API_TOKEN", "xyz", "user_id", 7)
+	checkLogFormatMatches(t, "authorization=[REDACTED] API_TOKEN=[REDACTED] user_id=7 msg=request level=info\n", &buf)
+}
+
+func TestRedactorScrubsValues(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	logger.SetRedactor(NewRedactor().ScrubValues(BearerTokenPattern, EmailPattern))
+
+	logger.LogErrorMessage("failed for jane@example.com", "header", "Bearer abc.def-123", "error", errors.New("no user bob@example.com"), "count", 3)
+	checkLogFormatMatches(t, "header=[REDACTED] error=\"no user [REDACTED]\" error_type=*errors.errorString count=3 msg=\"failed for [REDACTED]\" level=error\n", &buf)
+}
+
+func TestRedactorAppliesToStandardFields(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	logger.SetRedactor(NewRedactor("password").ScrubValues(EmailPattern))
+
+	l2 := logger.With("password", "hunter2", "email", "jane@example.com")
+	l2.LogInfoMessage("signed up", "password", "hunter2")
+	checkLogFormatMatches(t, "password=[REDACTED] email=[REDACTED] password=[REDACTED] msg=\"signed up\" level=info\n", &buf)
+}
+
+func TestNewRedactorPanicsOnMalformedPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("want a panic for a malformed pattern")
+		}
+	}()
+	NewRedactor("password[")
+}
@@ -0,0 +1,12 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+// reopenSignals are the signals FileLoggerTo reopens its file on.
+var reopenSignals = []os.Signal{syscall.SIGHUP}
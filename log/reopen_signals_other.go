@@ -0,0 +1,9 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package log
+
+import "os"
+
+// reopenSignals are the signals FileLoggerTo reopens its file on; there is no SIGHUP here.
+var reopenSignals = []os.Signal{}
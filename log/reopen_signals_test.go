@@ -0,0 +1,41 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileWriterReopensOnSignal(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	w, _ := NewRotatingFileWriter(path, RotationConfig{})
+	defer w.Close()
+	w.ReopenOnSignal(syscall.SIGHUP)
+	w.Write([]byte("before\n"))
+	os.Rename(path, path+".moved") // as logrotate would
+
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	deadline := time.Now().Add(time.Second)
+	for !fileExists(path) {
+		if time.Now().After(deadline) {
+			t.Fatal("file not reopened after SIGHUP")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	w.Write([]byte("after\n"))
+
+	if want, have := "after\n", readFile(t, path); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "before\n", readFile(t, path+".moved"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
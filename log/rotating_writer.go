@@ -0,0 +1,353 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRotatingWriterClosed is returned when writing to a closed RotatingFileWriter.
+var ErrRotatingWriterClosed = errors.New("rotating log writer closed")
+
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotationConfig controls when a RotatingFileWriter rotates and what it keeps.
+// Zero values disable the corresponding behaviour.
+type RotationConfig struct {
+	// MaxSize rotates the file before a write would take it over this many bytes.
+	MaxSize int64
+	// MaxAge rotates the file once it has been written to for this long.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files to keep; older ones are removed.
+	MaxBackups int
+	// Compress gzips rotated files.
+	Compress bool
+}
+
+// RotatingFileWriter is an io.Writer appending to a file, which it rotates by size and/or age.
+// Rotated files are renamed with a timestamp suffix, e.g. app.log.2017-06-01T10-00-00.000(.gz).
+type RotatingFileWriter struct {
+	path   string
+	config RotationConfig
+
+	mu sync.Mutex
+	// file is nil after a failed rotation or reopen, and opened again by the next write.
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+	signals  chan os.Signal
+
+	// rotated files waiting to be compressed and/or removed, by a single cleanup goroutine
+	pending     []string
+	cleanupWake chan struct{}
+	cleanupDone chan struct{}
+}
+
+// NewRotatingFileWriter opens, or creates, the file at path for appending.
+func NewRotatingFileWriter(path string, config RotationConfig) (*RotatingFileWriter, error) {
+	w := &RotatingFileWriter{path: path, config: config}
+	if err := w.openLocked(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// FileLoggerTo returns a JSON format logger writing to a rotating file at path, which is reopened
+// on SIGHUP where supported so external tools like logrotate can move it. Close the returned writer on shutdown.
+func FileLoggerTo(path string, config RotationConfig) (*CoreLogger, *RotatingFileWriter, error) {
+	w, err := NewRotatingFileWriter(path, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	w.ReopenOnSignal(reopenSignals...)
+	return JSONLoggerTo(w), w, nil
+}
+
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrRotatingWriterClosed
+	}
+	if w.file == nil {
+		if err := w.openLocked(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.shouldRotateLocked(len(p)) {
+		if err := w.rotateLocked(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate renames the current file to a backup and starts a new one.
+func (w *RotatingFileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrRotatingWriterClosed
+	}
+	return w.rotateLocked()
+}
+
+// Reopen closes and reopens the file at path, for when it has been moved by another process.
+// If the file can't be reopened, the next write tries again.
+func (w *RotatingFileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrRotatingWriterClosed
+	}
+	if err := w.closeFileLocked(); err != nil {
+		return err
+	}
+	return w.openLocked()
+}
+
+// ReopenOnSignal reopens the file whenever one of the signals is received, until Close.
+func (w *RotatingFileWriter) ReopenOnSignal(sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed || w.signals != nil {
+		return
+	}
+	w.signals = make(chan os.Signal, 1)
+	signal.Notify(w.signals, sigs...)
+	go func(signals chan os.Signal) {
+		for range signals {
+			w.Reopen()
+		}
+	}(w.signals)
+}
+
+// Close closes the file, waiting for any compression of rotated files to finish.
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	if w.signals != nil {
+		signal.Stop(w.signals)
+		close(w.signals)
+	}
+	if w.cleanupWake != nil {
+		close(w.cleanupWake)
+	}
+	err := w.closeFileLocked()
+	cleanupDone := w.cleanupDone
+	w.mu.Unlock()
+
+	if cleanupDone != nil {
+		<-cleanupDone
+	}
+	return err
+}
+
+func (w *RotatingFileWriter) shouldRotateLocked(writeSize int) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.config.MaxSize > 0 && w.size+int64(writeSize) > w.config.MaxSize {
+		return true
+	}
+	return w.config.MaxAge > 0 && time.Since(w.openedAt) >= w.config.MaxAge
+}
+
+func (w *RotatingFileWriter) openLocked() error {
+	file, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = time.Now()
+	return nil
+}
+
+// closeFileLocked closes the file, if open, leaving it to be opened again.
+func (w *RotatingFileWriter) closeFileLocked() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *RotatingFileWriter) rotateLocked() error {
+	if err := w.closeFileLocked(); err != nil {
+		return err
+	}
+	backup := w.backupName(time.Now())
+	if err := os.Rename(w.path, backup); err != nil {
+		return err
+	}
+	if err := w.openLocked(); err != nil {
+		return err
+	}
+
+	if w.config.Compress || w.config.MaxBackups > 0 {
+		w.pending = append(w.pending, backup)
+		if w.cleanupWake == nil {
+			w.cleanupWake = make(chan struct{}, 1)
+			w.cleanupDone = make(chan struct{})
+			go w.cleanupRotated(w.cleanupWake, w.cleanupDone)
+		}
+		select {
+		case w.cleanupWake <- struct{}{}:
+		default: // already woken
+		}
+	}
+	return nil
+}
+
+// cleanupRotated compresses rotated files and removes old backups, one rotation at a time, until wake is closed.
+func (w *RotatingFileWriter) cleanupRotated(wake <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	for range wake {
+		w.mu.Lock()
+		pending := w.pending
+		w.pending = nil
+		w.mu.Unlock()
+
+		for _, backup := range pending {
+			if w.config.Compress {
+				compressFile(backup)
+			}
+		}
+		w.removeOldBackups()
+	}
+}
+
+// backupName returns an unused name for a rotated file.
+func (w *RotatingFileWriter) backupName(t time.Time) string {
+	name := w.path + "." + t.UTC().Format(backupTimeFormat)
+	candidate := name
+	for i := 1; fileExists(candidate) || fileExists(candidate+".gz"); i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+	return candidate
+}
+
+// backup is a rotated file, named by backupName.
+type backup struct {
+	name string // without any .gz
+	time time.Time
+	seq  int
+}
+
+// parseBackupName reports whether path is a name backupName would produce, returning it without any .gz.
+func (w *RotatingFileWriter) parseBackupName(path string) (backup, bool) {
+	if !strings.HasPrefix(path, w.path+".") {
+		return backup{}, false
+	}
+	name := strings.TrimSuffix(path, ".gz")
+	suffix := name[len(w.path)+1:]
+	if len(suffix) < len(backupTimeFormat) {
+		return backup{}, false
+	}
+	t, err := time.Parse(backupTimeFormat, suffix[:len(backupTimeFormat)])
+	if err != nil {
+		return backup{}, false
+	}
+	b := backup{name: name, time: t}
+	if rest := suffix[len(backupTimeFormat):]; rest != "" {
+		if !strings.HasPrefix(rest, "-") {
+			return backup{}, false
+		}
+		if b.seq, err = strconv.Atoi(rest[1:]); err != nil || b.seq < 1 {
+			return backup{}, false
+		}
+	}
+	return b, true
+}
+
+func (w *RotatingFileWriter) removeOldBackups() {
+	if w.config.MaxBackups <= 0 {
+		return
+	}
+	paths, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return
+	}
+	// count each backup once, whether or not it has been compressed yet
+	byName := map[string]backup{}
+	for _, path := range paths {
+		if b, ok := w.parseBackupName(path); ok {
+			byName[b.name] = b
+		}
+	}
+	backups := make([]backup, 0, len(byName))
+	for _, b := range byName {
+		backups = append(backups, b)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].time.Equal(backups[j].time) {
+			return backups[i].time.Before(backups[j].time)
+		}
+		return backups[i].seq < backups[j].seq
+	})
+	for i := 0; i < len(backups)-w.config.MaxBackups; i++ {
+		os.Remove(backups[i].name)
+		os.Remove(backups[i].name + ".gz")
+	}
+}
+
+// compressFile gzips path to path.gz, removing the original.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
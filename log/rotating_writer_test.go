@@ -0,0 +1,247 @@
+package log
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileWriterRotatesBySize(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	w, err := NewRotatingFileWriter(path, RotationConfig{MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("12345\n"))
+	w.Write([]byte("67890\n")) // would take the file over 10 bytes
+	w.Close()
+
+	if want, have := "67890\n", readFile(t, path); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	backups := backupsOf(t, path)
+	if len(backups) != 1 {
+		t.Fatalf("want 1 backup, have %v", backups)
+	}
+	if want, have := "12345\n", readFile(t, backups[0]); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestRotatingFileWriterKeepsMaxBackups(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	w, _ := NewRotatingFileWriter(path, RotationConfig{MaxBackups: 2})
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n"} {
+		w.Write([]byte(line))
+		w.Rotate()
+	}
+	w.Close()
+
+	backups := backupsOf(t, path)
+	if len(backups) != 2 {
+		t.Fatalf("want 2 backups, have %v", backups)
+	}
+	if want, have := "3\n", readFile(t, backups[0]); want != have {
+		t.Errorf("want oldest kept backup %q, have %q", want, have)
+	}
+}
+
+func TestRotatingFileWriterCompresses(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	w, _ := NewRotatingFileWriter(path, RotationConfig{Compress: true})
+	w.Write([]byte("hello\n"))
+	w.Rotate()
+	w.Close()
+
+	backups := backupsOf(t, path)
+	if len(backups) != 1 || filepath.Ext(backups[0]) != ".gz" {
+		t.Fatalf("want 1 gzipped backup, have %v", backups)
+	}
+	f, _ := os.Open(backups[0])
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := ioutil.ReadAll(gz)
+	if want, have := "hello\n", string(content); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestRotatingFileWriterReopen(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	w, _ := NewRotatingFileWriter(path, RotationConfig{})
+	w.Write([]byte("before\n"))
+	os.Rename(path, path+".moved") // as logrotate would
+	w.Reopen()
+	w.Write([]byte("after\n"))
+	w.Close()
+
+	if want, have := "after\n", readFile(t, path); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if _, err := w.Write([]byte("closed\n")); err != ErrRotatingWriterClosed {
+		t.Errorf("want %v, have %v", ErrRotatingWriterClosed, err)
+	}
+}
+
+func TestRotatingFileWriterRotatesByAge(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	w, _ := NewRotatingFileWriter(path, RotationConfig{MaxAge: 10 * time.Millisecond})
+	w.Write([]byte("old\n"))
+	w.Write([]byte("recent\n"))
+	time.Sleep(20 * time.Millisecond)
+	w.Write([]byte("new\n"))
+	w.Close()
+
+	if want, have := "new\n", readFile(t, path); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	backups := backupsOf(t, path)
+	if len(backups) != 1 {
+		t.Fatalf("want 1 backup, have %v", backups)
+	}
+	if want, have := "old\nrecent\n", readFile(t, backups[0]); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestRotatingFileWriterCompressesAndKeepsMaxBackups(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	// files alongside that the writer didn't create, e.g. from logrotate
+	for _, other := range []string{path + ".1", path + ".lock", path + ".2017-06-01"} {
+		ioutil.WriteFile(other, []byte("other\n"), 0644)
+	}
+
+	w, _ := NewRotatingFileWriter(path, RotationConfig{MaxBackups: 2, Compress: true})
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n", "5\n"} {
+		w.Write([]byte(line))
+		w.Rotate()
+	}
+	w.Close()
+
+	gzipped, _ := filepath.Glob(path + ".*.gz")
+	if len(gzipped) != 2 {
+		t.Errorf("want 2 gzipped backups, have %v", gzipped)
+	}
+	if backups := backupsOf(t, path); len(backups) != 5 {
+		t.Errorf("want 2 backups and 3 other files, have %v", backups)
+	}
+	for _, other := range []string{path + ".1", path + ".lock", path + ".2017-06-01"} {
+		if !fileExists(other) {
+			t.Errorf("want %s kept", other)
+		}
+	}
+}
+
+func TestRotatingFileWriterRecoversFromFailedReopen(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	w, _ := NewRotatingFileWriter(path, RotationConfig{})
+	defer w.Close()
+	w.Write([]byte("before\n"))
+	os.Remove(path)
+	os.Mkdir(path, 0755) // can't be opened for writing
+
+	if err := w.Reopen(); err == nil {
+		t.Errorf("want error reopening a directory")
+	}
+	if _, err := w.Write([]byte("lost\n")); err == nil {
+		t.Errorf("want error writing while the file can't be opened")
+	}
+	os.Remove(path)
+	if _, err := w.Write([]byte("after\n")); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "after\n", readFile(t, path); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestRotatingFileWriterRecoversFromFailedRotate(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	w, _ := NewRotatingFileWriter(path, RotationConfig{})
+	defer w.Close()
+	w.Write([]byte("before\n"))
+	os.Remove(path) // nothing to rename
+
+	if err := w.Rotate(); err == nil {
+		t.Errorf("want error rotating a removed file")
+	}
+	if _, err := w.Write([]byte("after\n")); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "after\n", readFile(t, path); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestFileLoggerTo(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	logger, w, err := FileLoggerTo(path, RotationConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.hideTimestamp = true
+	logger.LogInfoMessage("hello")
+	w.Close()
+
+	if want, have := "{\"level\":\"info\",\"msg\":\"hello\"}\n", readFile(t, path); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "gocore-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func readFile(t *testing.T, path string) string {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func backupsOf(t *testing.T, path string) []string {
+	backups, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(backups)
+	return backups
+}
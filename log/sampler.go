@@ -0,0 +1,156 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Sampler limits repeated log lines. Within each interval, the first N lines with a given
+// level and msg are logged, then every Mth; the rest are dropped and counted.
+// At the end of an interval a summary line is logged for each msg that had lines dropped.
+type Sampler struct {
+	first      int
+	thereafter int
+	interval   time.Duration
+
+	mu         sync.Mutex
+	windowEnd  time.Time
+	counts     map[sampleKey]*sampleCount
+	flushTimer *time.Timer
+	emit       func(level Level, keyvals []interface{})
+}
+
+type sampleKey struct {
+	level Level
+	msg   string
+}
+
+type sampleCount struct {
+	seen    int
+	dropped int
+}
+
+type sampleSummary struct {
+	key     sampleKey
+	dropped int
+}
+
+// NewSampler returns a Sampler logging the first lines of each msg per interval, then every thereafter-th.
+// A thereafter of 0 drops everything after the first lines.
+func NewSampler(first, thereafter int, interval time.Duration) *Sampler {
+	return &Sampler{
+		first:      first,
+		thereafter: thereafter,
+		interval:   interval,
+		counts:     map[sampleKey]*sampleCount{},
+	}
+}
+
+// allow records an occurrence of the line and reports whether it should be logged.
+func (s *Sampler) allow(level Level, keyvals []interface{}) bool {
+	key := sampleKey{level: level, msg: messageFrom(keyvals)}
+	now := time.Now()
+
+	s.mu.Lock()
+	var summaries []sampleSummary
+	if !now.Before(s.windowEnd) {
+		summaries = s.resetLocked(now)
+	}
+	count, ok := s.counts[key]
+	if !ok {
+		count = &sampleCount{}
+		s.counts[key] = count
+	}
+	count.seen++
+	n := count.seen
+	allowed := n <= s.first || (s.thereafter > 0 && (n-s.first)%s.thereafter == 0)
+	if !allowed {
+		count.dropped++
+		if s.flushTimer == nil {
+			s.flushTimer = time.AfterFunc(s.windowEnd.Sub(now), s.flush)
+		}
+	}
+	emit := s.emit
+	s.mu.Unlock()
+
+	s.emitSummaries(emit, summaries)
+	return allowed
+}
+
+// flush ends the current interval if it has elapsed, logging any summaries.
+func (s *Sampler) flush() {
+	now := time.Now()
+	s.mu.Lock()
+	var summaries []sampleSummary
+	if !now.Before(s.windowEnd) {
+		summaries = s.resetLocked(now)
+	}
+	emit := s.emit
+	s.mu.Unlock()
+
+	s.emitSummaries(emit, summaries)
+}
+
+// resetLocked starts a new interval, returning summary lines for the previous one.
+func (s *Sampler) resetLocked(now time.Time) []sampleSummary {
+	var summaries []sampleSummary
+	for key, count := range s.counts {
+		if count.dropped > 0 {
+			summaries = append(summaries, sampleSummary{key: key, dropped: count.dropped})
+		}
+	}
+	if s.flushTimer != nil {
+		s.flushTimer.Stop()
+		s.flushTimer = nil
+	}
+	s.counts = map[sampleKey]*sampleCount{}
+	s.windowEnd = now.Add(s.interval)
+	return summaries
+}
+
+func (s *Sampler) emitSummaries(emit func(level Level, keyvals []interface{}), summaries []sampleSummary) {
+	if emit == nil {
+		return
+	}
+	for _, summary := range summaries {
+		emit(summary.key.level, []interface{}{
+			"sampled_msg", summary.key.msg,
+			"dropped", summary.dropped,
+			"msg", "log lines dropped by sampling",
+		})
+	}
+}
+
+// messageFrom returns the value of the last "msg" key in keyvals as a string, e.g. an error's message, or empty.
+// A lazy value is evaluated.
+func messageFrom(keyvals []interface{}) string {
+	msg := ""
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if key, ok := keyvals[i].(string); ok && key == "msg" {
+			value := keyvals[i+1]
+			if fn, ok := lazyFunc(value); ok {
+				value = fn()
+			}
+			msg = fmt.Sprint(value)
+		}
+	}
+	return msg
+}
+
+// resolveMessage returns keyvals with lazy "msg" values evaluated, in a copy if there are any,
+// so a message sampled by its value isn't evaluated again when written.
+func resolveMessage(keyvals []interface{}) []interface{} {
+	resolved, copied := keyvals, false
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if key, ok := keyvals[i].(string); ok && key == "msg" {
+			if fn, ok := lazyFunc(keyvals[i+1]); ok {
+				if !copied {
+					resolved, copied = append([]interface{}{}, keyvals...), true
+				}
+				resolved[i+1] = fn()
+			}
+		}
+	}
+	return resolved
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplerFirstThenEveryNth(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	logger.SetSampler(NewSampler(2, 3, time.Hour))
+
+	for i := 1; i <= 10; i++ {
+		logger.LogErrorMessage("boom", "i", i)
+	}
+	logger.LogInfoMessage("boom") // different level, sampled separately
+	checkLogFormatMatches(t, "i=1 msg=boom level=error\ni=2 msg=boom level=error\ni=5 msg=boom level=error\ni=8 msg=boom level=error\nmsg=boom level=info\n", &buf)
+}
+
+func TestSamplerSharedWithStandardFields(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	logger.SetSampler(NewSampler(1, 0, time.Hour))
+
+	logger.LogErrorMessage("boom")
+	logger.With("foo", "bar").LogErrorMessage("boom")
+	checkLogFormatMatches(t, "msg=boom level=error\n", &buf)
+}
+
+func TestSamplerKeysOnErrorMessages(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	logger.SetSampler(NewSampler(1, 0, time.Hour))
+
+	logger.LogError(errors.New("disk full"))
+	logger.LogError(errors.New("connection refused"))
+	logger.LogError(errors.New("disk full"))
+	checkLogFormatMatches(t, "msg=\"disk full\" level=error\nmsg=\"connection refused\" level=error\n", &buf)
+}
+
+func TestSamplerKeysOnLazyMessages(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	logger.SetSampler(NewSampler(1, 0, time.Hour))
+
+	evaluated := 0
+	message := func(msg string) Lazy {
+		return func() interface{} {
+			evaluated++
+			return msg
+		}
+	}
+	logger.LogError("msg", message("disk full"))
+	logger.LogError("msg", message("connection refused"))
+	logger.LogError("msg", message("disk full"))
+	checkLogFormatMatches(t, "msg=\"disk full\" level=error\nmsg=\"connection refused\" level=error\n", &buf)
+	if want, have := 3, evaluated; want != have {
+		t.Errorf("want each message evaluated once, have %d evaluations", have)
+	}
+}
+
+func TestSamplerSummary(t *testing.T) {
+	buf := &lockedBuffer{}
+	logger := LogfmtLoggerTo(buf)
+	logger.hideTimestamp = true
+	logger.SetSampler(NewSampler(1, 0, 20*time.Millisecond))
+
+	for i := 0; i < 5; i++ {
+		logger.LogErrorMessage("boom")
+	}
+	time.Sleep(100 * time.Millisecond)
+	logger.LogErrorMessage("boom")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if want, have := 3, len(lines); want != have {
+		t.Fatalf("want %d lines, have %d: %q", want, have, lines)
+	}
+	if want, have := "sampled_msg=boom dropped=4 msg=\"log lines dropped by sampling\" level=error", lines[1]; want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
@@ -0,0 +1,205 @@
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// SlogHandler is a slog.Handler that writes records through a Logger, so slog users share
+// its output format, standard fields and levels.
+type SlogHandler struct {
+	logger Logger
+	group  string
+}
+
+func NewSlogHandler(logger Logger) *SlogHandler {
+	return &SlogHandler{logger: logger}
+}
+
+// NewSlogLogger returns a *slog.Logger writing through the Logger.
+func NewSlogLogger(logger Logger) *slog.Logger {
+	return slog.New(NewSlogHandler(logger))
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if cl, ok := h.logger.(*CoreLogger); ok {
+		return fromSlogLevel(level) >= cl.minLevel()
+	}
+	return true
+}
+
+func (h *SlogHandler) Handle(_ context.Context, record slog.Record) error {
+	keyvals := make([]interface{}, 0, record.NumAttrs()*2)
+	record.Attrs(func(attr slog.Attr) bool {
+		keyvals = appendSlogAttr(keyvals, h.group, attr)
+		return true
+	})
+
+	switch fromSlogLevel(record.Level) {
+	case DebugLevel:
+		h.logger.LogDebugMessage(record.Message, keyvals...)
+	case InfoLevel:
+		h.logger.LogInfoMessage(record.Message, keyvals...)
+	case WarnLevel:
+		h.logger.LogWarnMessage(record.Message, keyvals...)
+	default:
+		h.logger.LogErrorMessage(record.Message, keyvals...)
+	}
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	keyvals := []interface{}{}
+	for _, attr := range attrs {
+		keyvals = appendSlogAttr(keyvals, h.group, attr)
+	}
+	if len(keyvals) == 0 {
+		return h
+	}
+	return &SlogHandler{logger: h.logger.With(keyvals...), group: h.group}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{logger: h.logger, group: groupKey(h.group, name)}
+}
+
+// appendSlogAttr flattens an attribute onto keyvals, with groups as dotted key prefixes.
+func appendSlogAttr(keyvals []interface{}, group string, attr slog.Attr) []interface{} {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return keyvals
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		prefix := group
+		if attr.Key != "" {
+			prefix = groupKey(group, attr.Key)
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			keyvals = appendSlogAttr(keyvals, prefix, groupAttr)
+		}
+		return keyvals
+	}
+	return append(keyvals, groupKey(group, attr.Key), attr.Value.Any())
+}
+
+func groupKey(group, key string) string {
+	if group == "" {
+		return key
+	}
+	return group + "." + key
+}
+
+// SlogAdapter is a Logger that writes to a slog.Handler.
+type SlogAdapter struct {
+	handler slog.Handler
+}
+
+func NewSlogAdapter(handler slog.Handler) *SlogAdapter {
+	return &SlogAdapter{handler: handler}
+}
+
+func (sa *SlogAdapter) LogDebugMessage(message string, keyvalues ...interface{}) {
+	sa.LogDebug(append(keyvalues, "msg", message)...)
+}
+
+func (sa *SlogAdapter) LogInfoMessage(message string, keyvalues ...interface{}) {
+	sa.LogInfo(append(keyvalues, "msg", message)...)
+}
+
+func (sa *SlogAdapter) LogWarnMessage(message string, keyvalues ...interface{}) {
+	sa.LogWarn(append(keyvalues, "msg", message)...)
+}
+
+func (sa *SlogAdapter) LogErrorMessage(message string, keyvalues ...interface{}) {
+	sa.LogError(append(keyvalues, "msg", message)...)
+}
+
+func (sa *SlogAdapter) LogDebug(keyvals ...interface{}) {
+	sa.logAt(slog.LevelDebug, keyvals)
+}
+
+func (sa *SlogAdapter) LogInfo(keyvals ...interface{}) {
+	sa.logAt(slog.LevelInfo, keyvals)
+}
+
+func (sa *SlogAdapter) LogWarn(keyvals ...interface{}) {
+	sa.logAt(slog.LevelWarn, keyvals)
+}
+
+func (sa *SlogAdapter) LogError(keyvals ...interface{}) {
+	sa.logAt(slog.LevelError, keyvals)
+}
+
+func (sa *SlogAdapter) With(keyvals ...interface{}) Logger {
+	return &SlogAdapter{handler: sa.handler.WithAttrs(slogAttrs(keyvals))}
+}
+
+func (sa *SlogAdapter) logAt(level slog.Level, keyvals []interface{}) {
+	ctx := context.Background()
+	if !sa.handler.Enabled(ctx, level) {
+		return
+	}
+	if len(keyvals) == 1 {
+		keyvals = []interface{}{"msg", keyvals[0]}
+	}
+	if len(keyvals)%2 == 1 {
+		keyvals = append(keyvals, nil) // missing a value
+	}
+
+	// the "msg" key becomes the record message, everything else an attribute
+	message := ""
+	rest := make([]interface{}, 0, len(keyvals))
+	for i := 0; i < len(keyvals); i += 2 {
+		if keyvals[i] == "msg" {
+			message = fmt.Sprint(keyvals[i+1])
+			continue
+		}
+		rest = append(rest, keyvals[i], keyvals[i+1])
+	}
+
+	record := slog.NewRecord(time.Now(), level, message, 0)
+	record.AddAttrs(slogAttrs(rest)...)
+	sa.handler.Handle(ctx, record)
+}
+
+func slogAttrs(keyvals []interface{}) []slog.Attr {
+	if len(keyvals)%2 == 1 {
+		keyvals = append(keyvals, nil) // missing a value
+	}
+	attrs := make([]slog.Attr, 0, len(keyvals)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		value := keyvals[i+1]
+		if fn, ok := lazyFunc(value); ok {
+			value = lazyLogValuer(fn)
+		}
+		attrs = append(attrs, slog.Any(fmt.Sprint(keyvals[i]), value))
+	}
+	return attrs
+}
+
+// lazyLogValuer defers a Lazy value until the handler resolves it.
+type lazyLogValuer func() interface{}
+
+func (fn lazyLogValuer) LogValue() slog.Value {
+	return slog.AnyValue(fn())
+}
+
+func fromSlogLevel(level slog.Level) Level {
+	switch {
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slog.LevelError:
+		return WarnLevel
+	}
+	return ErrorLevel
+}
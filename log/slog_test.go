@@ -0,0 +1,78 @@
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	slogger := NewSlogLogger(logger.With("service", "api"))
+
+	slogger.Info("hello", "foo", 4)
+	checkLogFormatMatches(t, "service=api foo=4 msg=hello level=info\n", &buf)
+
+	slogger.With("user", 7).WithGroup("req").Warn("slow", slog.Int("ms", 300), slog.Group("db", "table", "users"))
+	checkLogFormatMatches(t, "service=api user=7 req.ms=300 req.db.table=users msg=slow level=warn\n", &buf)
+}
+
+func TestSlogHandlerLevels(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := LogfmtLoggerTo(&buf)
+	logger.hideTimestamp = true
+	slogger := NewSlogLogger(logger)
+
+	slogger.Debug("hidden")
+	checkLogFormatMatches(t, "", &buf)
+
+	logger.SetLevel(DebugLevel)
+	slogger.Debug("shown")
+	checkLogFormatMatches(t, "msg=shown level=debug\n", &buf)
+
+	slogger.Error("failed")
+	checkLogFormatMatches(t, "msg=failed level=error\n", &buf)
+}
+
+func TestSlogAdapter(t *testing.T) {
+	buf := bytes.Buffer{}
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger := NewSlogAdapter(handler).With("service", "api")
+
+	logger.LogWarnMessage("slow", "ms", 300)
+	checkLogFormatMatches(t, "level=WARN msg=slow service=api ms=300\n", &buf)
+
+	logger.LogDebugMessage("hidden")
+	checkLogFormatMatches(t, "", &buf)
+
+	logger.LogError("failed")
+	checkLogFormatMatches(t, "level=ERROR msg=failed service=api\n", &buf)
+}
+
+func TestSlogAdapterLazyValue(t *testing.T) {
+	buf := bytes.Buffer{}
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger := NewSlogAdapter(handler)
+
+	logger.LogInfoMessage("hello", "count", Lazy(func() interface{} { return 3 }))
+	checkLogFormatMatches(t, "level=INFO msg=hello count=3\n", &buf)
+}
@@ -0,0 +1,229 @@
+package log
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogLoggerWritesRFC5424OverUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	logger, _, err := SyslogLoggerTo(SyslogConfig{Network: "udp", Address: conn.LocalAddr().String(), AppName: "app", Hostname: "host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.SetStandardFields("service", "billing").LogWarnMessage("card declined", "note", `say "hi" [x]`)
+
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := regexp.MustCompile(`^<12>1 \S+Z host app \d+ - \[fields@32473 service="billing" note="say \\"hi\\" \[x\\]"\] card declined$`)
+	if have := string(buf[:n]); !want.MatchString(have) {
+		t.Errorf("want match for %v, have %q", want, have)
+	}
+}
+
+func TestSyslogLoggerWithFormat(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	logger, _, err := SyslogLoggerTo(SyslogConfig{Network: "udp", Address: conn.LocalAddr().String(), AppName: "app", Hostname: "host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.SetFormat(ECSFormat)
+	logger.With("service", "billing").LogErrorMessage("card declined")
+
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := regexp.MustCompile(`^<11>1 \S+Z host app \d+ - \[fields@32473 service="billing"\] card declined$`)
+	if have := string(buf[:n]); !want.MatchString(have) {
+		t.Errorf("want match for %v, have %q", want, have)
+	}
+}
+
+func TestSyslogLoggerFramesAndReconnectsOverTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	logger, _, err := SyslogLoggerTo(SyslogConfig{Network: "tcp", Address: listener.Addr().String(), Facility: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.LogErrorMessage("first")
+	first, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := readSyslogFrame(t, first); !strings.HasPrefix(have, "<131>1 ") || !strings.HasSuffix(have, " - first") {
+		t.Errorf("unexpected message %q", have)
+	}
+
+	// the server dropping the connection is only noticed on a later write, so keep logging until it reconnects
+	first.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+	var second net.Conn
+	deadline := time.After(time.Second)
+	for second == nil {
+		logger.LogInfoMessage("second")
+		select {
+		case second = <-accepted:
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("logger didn't reconnect")
+		}
+	}
+	defer second.Close()
+	if have := readSyslogFrame(t, second); !strings.HasPrefix(have, "<134>1 ") || !strings.HasSuffix(have, " - second") {
+		t.Errorf("unexpected message %q", have)
+	}
+}
+
+func TestSyslogLoggerConnectsWhenServerStarts(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	logger, closer, err := SyslogLoggerTo(SyslogConfig{Network: "tcp", Address: address})
+	if err != nil {
+		t.Fatalf("want no error with the server down, have %v", err)
+	}
+	defer closer.Close()
+	logger.LogErrorMessage("lost")
+
+	if listener, err = net.Listen("tcp", address); err != nil {
+		t.Skipf("can't listen on %s again: %v", address, err)
+	}
+	defer listener.Close()
+	// lines are dropped until the reconnection attempt is due, so keep logging until it connects
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+	var conn net.Conn
+	deadline := time.After(time.Second)
+	for conn == nil {
+		logger.LogErrorMessage("delivered")
+		select {
+		case conn = <-accepted:
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("logger didn't connect")
+		}
+	}
+	defer conn.Close()
+	if have := readSyslogFrame(t, conn); !strings.HasSuffix(have, " - delivered") {
+		t.Errorf("unexpected message %q", have)
+	}
+}
+
+func TestReconnectingConnBacksOffFromDeadServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	conn, err := newReconnectingConn("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := conn.write([]byte("lost")); err == nil || err == errNotConnected {
+		t.Fatalf("want a dial error, have %v", err)
+	}
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		if err := conn.write([]byte("dropped")); err != errNotConnected {
+			t.Fatalf("want %v while backing off, have %v", errNotConnected, err)
+		}
+	}
+	if took := time.Since(start); took > 50*time.Millisecond {
+		t.Errorf("want writes dropped without redialling, took %v", took)
+	}
+}
+
+func TestSyslogLoggerCloseDropsLaterLines(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	logger, closer, err := SyslogLoggerTo(SyslogConfig{Network: "udp", Address: conn.LocalAddr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Logger.Log("msg", "dropped"); err != errConnClosed {
+		t.Errorf("want %v, have %v", errConnClosed, err)
+	}
+}
+
+func TestSyslogLoggerRejectsUnknownNetwork(t *testing.T) {
+	if _, _, err := SyslogLoggerTo(SyslogConfig{Network: "carrier-pigeon", Address: "loft"}); err == nil {
+		t.Errorf("want error for an unknown network")
+	}
+}
+
+func TestSyslogParamNameIsSanitized(t *testing.T) {
+	if want, have := "a_b_c_d", syslogParamName(`a=b c"d`); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+// readSyslogFrame reads an octet counted message.
+func readSyslogFrame(t *testing.T, conn net.Conn) string {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	reader := bufio.NewReader(conn)
+	length, err := reader.ReadString(' ')
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(length))
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := make([]byte, n)
+	if _, err := io.ReadFull(reader, message); err != nil {
+		t.Fatal(err)
+	}
+	return string(message)
+}
@@ -2,17 +2,19 @@ package metrics
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 import (
+	"github.com/DataDog/datadog-go/statsd"
 	"github.com/armon/go-metrics"
-	"github.com/armon/go-metrics/datadog"
 )
 
 // DatadogStatsdRecorder wraps a StatsdRecorder and allows tagging of metrics
 type DatadogStatsdRecorder struct {
 	*StatsdRecorder
-	sink *datadog.DogStatsdSink // we need direct access to the strongly typed underlying sink
+	sink *dogStatsdSink // we need direct access to the strongly typed underlying sink
 	tags []metrics.Label
 }
 
@@ -20,14 +22,15 @@ func NewDatadogStatsdRecorder(statsiteEndpoint, namespace, hostname string) (*Da
 	if statsiteEndpoint == "" {
 		return nil, errors.New("Uninitialized DatadogStatsdRecorder")
 	}
-	sink, err := datadog.NewDogStatsdSink(statsiteEndpoint, hostname)
+	client, err := statsd.New(statsiteEndpoint)
 	if err != nil {
 		return nil, err
 	}
+	sink := &dogStatsdSink{client: client, hostName: hostname}
 	config := metrics.DefaultConfig(namespace)
 	config.EnableHostname = false
 	m, _ := metrics.New(config, sink)
-	return &DatadogStatsdRecorder{StatsdRecorder: &StatsdRecorder{m, ""}, sink: sink, tags: []metrics.Label{}}, nil
+	return &DatadogStatsdRecorder{StatsdRecorder: &StatsdRecorder{Metrics: m}, sink: sink, tags: []metrics.Label{}}, nil
 }
 
 func (dd *DatadogStatsdRecorder) IncrementCount(metricName string) {
@@ -65,6 +68,33 @@ func (dd *DatadogStatsdRecorder) SetGauge(metricName string, val float32) {
 	)
 }
 
+// Histogram is sent as a DogStatsD histogram, aggregated per host.
+func (dd *DatadogStatsdRecorder) Histogram(metricName string, value float32) {
+	dd.sink.addHistogramWithLabels(
+		dd.withPrefixAndServiceName(metricName, "histogram"),
+		value,
+		dd.tags,
+	)
+}
+
+// Distribution is sent as a DogStatsD distribution, aggregated globally for percentiles across hosts.
+func (dd *DatadogStatsdRecorder) Distribution(metricName string, value float32) {
+	dd.sink.addDistributionWithLabels(
+		dd.withPrefixAndServiceName(metricName, "distribution"),
+		value,
+		dd.tags,
+	)
+}
+
+// AddToSet is sent as a DogStatsD set, counting unique values per flush interval.
+func (dd *DatadogStatsdRecorder) AddToSet(metricName string, value string) {
+	dd.sink.addToSetWithLabels(
+		dd.withPrefixAndServiceName(metricName, "set"),
+		value,
+		dd.tags,
+	)
+}
+
 // WithTag returns a new DatadogStatsdRecorder that has the tags added to it.
 func (dd *DatadogStatsdRecorder) WithTag(key, value string) MetricsRecorder {
 	newRecorder := &DatadogStatsdRecorder{StatsdRecorder: dd.StatsdRecorder, sink: dd.sink, tags: []metrics.Label{}}
@@ -77,6 +107,11 @@ func (dd *DatadogStatsdRecorder) GetTags() []metrics.Label {
 	return dd.tags
 }
 
+// Close flushes buffered metrics and closes the connection, for this recorder and those created from it via WithTag.
+func (dd *DatadogStatsdRecorder) Close() error {
+	return dd.sink.client.Close()
+}
+
 // adds prefix, service name prefix, and type prefix
 func (dd *DatadogStatsdRecorder) withPrefixAndServiceName(metricName, typeStr string) []string {
 	key := dd.prefixedMetricName(metricName)
@@ -89,6 +124,91 @@ func (dd *DatadogStatsdRecorder) withPrefixAndServiceName(metricName, typeStr st
 	return key
 }
 
+// dogStatsdSink is a go-metrics sink sending to DogStatsD, as github.com/armon/go-metrics/datadog's does,
+// which also sends the histograms, distributions and sets DogStatsD supports through the same client.
+type dogStatsdSink struct {
+	client   *statsd.Client
+	hostName string
+}
+
+func (s *dogStatsdSink) SetGauge(key []string, val float32) {
+	s.SetGaugeWithLabels(key, val, nil)
+}
+
+func (s *dogStatsdSink) SetGaugeWithLabels(key []string, val float32, labels []metrics.Label) {
+	flatKey, tags := s.flatKeyAndTags(key, labels)
+	s.client.Gauge(flatKey, float64(val), tags, 1)
+}
+
+// EmitKey is not implemented since DogStatsd does not provide a metric type that holds an
+// arbitrary number of values
+func (s *dogStatsdSink) EmitKey(key []string, val float32) {
+}
+
+func (s *dogStatsdSink) IncrCounter(key []string, val float32) {
+	s.IncrCounterWithLabels(key, val, nil)
+}
+
+func (s *dogStatsdSink) IncrCounterWithLabels(key []string, val float32, labels []metrics.Label) {
+	flatKey, tags := s.flatKeyAndTags(key, labels)
+	s.client.Count(flatKey, int64(val), tags, 1)
+}
+
+func (s *dogStatsdSink) AddSample(key []string, val float32) {
+	s.AddSampleWithLabels(key, val, nil)
+}
+
+func (s *dogStatsdSink) AddSampleWithLabels(key []string, val float32, labels []metrics.Label) {
+	flatKey, tags := s.flatKeyAndTags(key, labels)
+	s.client.TimeInMilliseconds(flatKey, float64(val), tags, 1)
+}
+
+func (s *dogStatsdSink) addHistogramWithLabels(key []string, val float32, labels []metrics.Label) {
+	flatKey, tags := s.flatKeyAndTags(key, labels)
+	s.client.Histogram(flatKey, float64(val), tags, 1)
+}
+
+func (s *dogStatsdSink) addDistributionWithLabels(key []string, val float32, labels []metrics.Label) {
+	flatKey, tags := s.flatKeyAndTags(key, labels)
+	s.client.Distribution(flatKey, float64(val), tags, 1)
+}
+
+func (s *dogStatsdSink) addToSetWithLabels(key []string, value string, labels []metrics.Label) {
+	flatKey, tags := s.flatKeyAndTags(key, labels)
+	s.client.Set(flatKey, value, tags, 1)
+}
+
+// flatKeyAndTags joins the key, without the hostname, into a metric name and formats labels as DogStatsD tags.
+func (s *dogStatsdSink) flatKeyAndTags(key []string, labels []metrics.Label) (string, []string) {
+	parts := make([]string, 0, len(key))
+	spliced := false
+	for _, part := range key {
+		if part == s.hostName && !spliced {
+			spliced = true // hosts are identified by the DogStatsD server instead
+			continue
+		}
+		parts = append(parts, part)
+	}
+
+	var tags []string
+	for _, label := range labels {
+		name, value := strings.Map(sanitizeDogStatsd, label.Name), strings.Map(sanitizeDogStatsd, label.Value)
+		if value != "" {
+			tags = append(tags, fmt.Sprintf("%s:%s", name, value))
+		} else {
+			tags = append(tags, name)
+		}
+	}
+	return strings.Map(sanitizeDogStatsd, strings.Join(parts, ".")), tags
+}
+
+func sanitizeDogStatsd(r rune) rune {
+	if r == ':' || r == ' ' {
+		return '_'
+	}
+	return r
+}
+
 // Inserts a string value at an index into the slice
 func insert(i int, v string, s []string) []string {
 	s = append(s, "")
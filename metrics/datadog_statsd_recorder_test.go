@@ -2,6 +2,7 @@ package metrics_test
 
 import (
 	"net"
+	"strings"
 	"testing"
 	"time"
 
@@ -103,3 +104,65 @@ func assertServerMatchesExpected(t *testing.T, server *net.UDPConn, buf []byte,
 		t.Fatalf("Line %s does not match expected: %s", string(msg), expected)
 	}
 }
+
+func TestDatadogStatsdHistogramAndDistribution(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	recorder, _ := metrics.NewDatadogStatsdRecorder(conn.LocalAddr().String(), "namespace", "hostname")
+	tagged := recorder.WithTag("tagkey", "tagvalue")
+	tagged.Histogram("payload", 512)
+	tagged.Distribution("batch", 20)
+
+	// distributions may be sent in a separate packet
+	have := readStatsd(t, conn)
+	for _, want := range []string{"namespace.payload:512|h|#tagkey:tagvalue", "namespace.batch:20|d|#tagkey:tagvalue"} {
+		if !strings.Contains(have, want) {
+			have += readStatsd(t, conn)
+		}
+		if !strings.Contains(have, want) {
+			t.Errorf("want %q in %q", want, have)
+		}
+	}
+}
+
+func TestDatadogStatsdAddToSet(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	recorder, _ := metrics.NewDatadogStatsdRecorder(conn.LocalAddr().String(), "namespace", "hostname")
+	recorder.WithTag("tagkey", "tagvalue").AddToSet("users", "alice")
+
+	if want, have := "namespace.users:alice|s|#tagkey:tagvalue", readStatsd(t, conn); !strings.Contains(have, want) {
+		t.Errorf("want %q in %q", want, have)
+	}
+}
+
+func TestDatadogStatsdClose(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	recorder, _ := metrics.NewDatadogStatsdRecorder(conn.LocalAddr().String(), "namespace", "hostname")
+	recorder.WithTag("tagkey", "tagvalue").IncrementCount("closing")
+	recorder.Histogram("payload", 512)
+
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	have := readStatsd(t, conn)
+	for _, want := range []string{"namespace.closing:1|c|#tagkey:tagvalue", "namespace.payload:512|h"} {
+		if !strings.Contains(have, want) {
+			have += readStatsd(t, conn)
+		}
+		if !strings.Contains(have, want) {
+			t.Errorf("want %q in %q", want, have)
+		}
+	}
+	recorder.IncrementCount("closed") // dropped, without panicking
+}
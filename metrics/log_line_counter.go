@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/intercom/gocore/log"
+)
+
+// maxLogLineRecorders bounds the tagged recorders a LogLineCounter keeps, as components may be unbounded.
+const maxLogLineRecorders = 256
+
+// LogLineCounter is a log.LineCounter incrementing log.LogLinesMetric in a MetricsRecorder for each line,
+// tagged with "level", and "component" where the line has one.
+type LogLineCounter struct {
+	recorder MetricsRecorder
+
+	mu     sync.Mutex
+	tagged map[logLineKey]MetricsRecorder
+}
+
+type logLineKey struct {
+	level     log.Level
+	component string
+}
+
+// NewLogLineCounter returns a LogLineCounter counting lines in recorder, for log.NewMetricsLogger.
+func NewLogLineCounter(recorder MetricsRecorder) *LogLineCounter {
+	return &LogLineCounter{recorder: recorder, tagged: map[logLineKey]MetricsRecorder{}}
+}
+
+func (c *LogLineCounter) CountLine(level log.Level, component string) {
+	c.recorderFor(logLineKey{level: level, component: component}).IncrementCount(log.LogLinesMetric)
+}
+
+// recorderFor returns the recorder tagged for key, caching it unless too many already are.
+func (c *LogLineCounter) recorderFor(key logLineKey) MetricsRecorder {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if recorder, ok := c.tagged[key]; ok {
+		return recorder
+	}
+	recorder := c.recorder.WithTag("level", key.level.String())
+	if key.component != "" {
+		recorder = recorder.WithTag(log.ComponentKey, key.component)
+	}
+	if len(c.tagged) < maxLogLineRecorders {
+		c.tagged[key] = recorder
+	}
+	return recorder
+}
@@ -0,0 +1,46 @@
+package metrics_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/intercom/gocore/log"
+	"github.com/intercom/gocore/metrics"
+	"github.com/intercom/gocore/metrics/metricstest"
+)
+
+func TestLogLineCounter(t *testing.T) {
+	recorder := metricstest.New()
+	logger := log.NewMetricsLogger(log.LogfmtLoggerTo(ioutil.Discard), metrics.NewLogLineCounter(recorder))
+	logger.SetComponentTag(true)
+
+	for i := 0; i < 3; i++ {
+		logger.LogInfoMessage("started")
+	}
+	logger.With(log.ComponentKey, "billing").LogErrorMessage("failed")
+
+	if want, have := 3, recorder.CounterValue(log.LogLinesMetric, map[string]string{"level": "info"}); want != have {
+		t.Errorf("want %d info lines, have %d", want, have)
+	}
+	if want, have := 1, recorder.CounterValue(log.LogLinesMetric, map[string]string{"level": "error", log.ComponentKey: "billing"}); want != have {
+		t.Errorf("want %d billing error lines, have %d", want, have)
+	}
+}
+
+func TestLogLineCounterCountsBeyondCachedComponents(t *testing.T) {
+	recorder := metricstest.New()
+	counter := metrics.NewLogLineCounter(recorder)
+
+	for i := 0; i < 1000; i++ {
+		counter.CountLine(log.WarnLevel, fmt.Sprint("worker-", i))
+	}
+	counter.CountLine(log.WarnLevel, "worker-999")
+
+	if want, have := 1001, recorder.CounterValue(log.LogLinesMetric, map[string]string{"level": "warn"}); want != have {
+		t.Errorf("want %d lines, have %d", want, have)
+	}
+	if want, have := 2, recorder.CounterValue(log.LogLinesMetric, map[string]string{log.ComponentKey: "worker-999"}); want != have {
+		t.Errorf("want %d lines for an uncached component, have %d", want, have)
+	}
+}
@@ -12,6 +12,9 @@ type MetricsRecorder interface {
 	MeasureSince(metricName string, since time.Time)
 	MeasureDurationMS(metricName string, durationMS float32)
 	SetGauge(metricName string, val float32)
+	Histogram(metricName string, value float32)
+	Distribution(metricName string, value float32)
+	AddToSet(metricName string, value string)
 	SetPrefix(prefix string)
 	WithTag(key, value string) MetricsRecorder
 }
@@ -53,6 +56,21 @@ func SetGauge(metricName string, val float32) {
 	globalMetrics.SetGauge(metricName, val)
 }
 
+// Record a value in a Histogram, aggregated per host, for Metric by name
+func Histogram(metricName string, value float32) {
+	globalMetrics.Histogram(metricName, value)
+}
+
+// Record a value in a Distribution, aggregated globally, for Metric by name
+func Distribution(metricName string, value float32) {
+	globalMetrics.Distribution(metricName, value)
+}
+
+// Add a value to a Set, counting unique values, for Metric by name
+func AddToSet(metricName string, value string) {
+	globalMetrics.AddToSet(metricName, value)
+}
+
 // Set Prefix for all Metrics collected
 func SetPrefix(prefix string) {
 	globalMetrics.SetPrefix(prefix)
@@ -2,23 +2,23 @@ package metrics_test
 
 import (
 	"testing"
-	"time"
 
 	"github.com/intercom/gocore/metrics"
+	"github.com/intercom/gocore/metrics/metricstest"
 )
 
 func TestSetMetricsGlobal(t *testing.T) {
 	metrics.IncrementCount("countMetric") // doesn't blow up when no global set
 
-	tr := TestRecorder{metrics: map[string]interface{}{}}
-	metrics.SetMetricsGlobal(&tr)
+	tr := metricstest.New()
+	metrics.SetMetricsGlobal(tr)
 	metrics.IncrementCount("countMetric")
-	if want, have := 1, tr.metrics["countMetric"]; want != have {
+	if want, have := 1, tr.CounterValue("countMetric", nil); want != have {
 		t.Errorf("want %#v, have %#v", want, have)
 	}
 
 	metrics.IncrementCountBy("countMetric", 3)
-	if want, have := 4, tr.metrics["countMetric"]; want != have {
+	if want, have := 4, tr.CounterValue("countMetric", nil); want != have {
 		t.Errorf("want %#v, have %#v", want, have)
 	}
 }
@@ -74,26 +74,3 @@ func TestGetTeedMetricsRecorder(t *testing.T) {
 		t.Errorf("want %s tag name, have %s tag name", want, have)
 	}
 }
-
-type TestRecorder struct {
-	metrics map[string]interface{}
-}
-
-func (tr *TestRecorder) IncrementCount(metricName string) {
-	tr.IncrementCountBy(metricName, 1)
-}
-
-func (tr *TestRecorder) IncrementCountBy(metricName string, val int) {
-	if _, present := tr.metrics[metricName]; present {
-		tr.metrics[metricName] = tr.metrics[metricName].(int) + val
-	} else {
-		tr.metrics[metricName] = val
-	}
-}
-
-// noops
-func (tr *TestRecorder) MeasureSince(string, time.Time)                    {}
-func (tr *TestRecorder) MeasureDurationMS(string, float32)                 {}
-func (tr *TestRecorder) SetGauge(string, float32)                          {}
-func (tr *TestRecorder) SetPrefix(string)                                  {}
-func (tr *TestRecorder) WithTag(key, value string) metrics.MetricsRecorder { return tr }
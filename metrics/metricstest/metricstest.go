@@ -0,0 +1,228 @@
+// Package metricstest provides a metrics.MetricsRecorder that records calls, for asserting on metrics in tests.
+package metricstest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intercom/gocore/metrics"
+)
+
+// The kinds of recorded call.
+const (
+	Count        = "count"
+	Timing       = "timing"
+	Gauge        = "gauge"
+	Histogram    = "histogram"
+	Distribution = "distribution"
+	Set          = "set"
+)
+
+// Call is a single recorded metric.
+type Call struct {
+	Kind string
+	// Name is the metric name as passed, without the prefix.
+	Name   string
+	Prefix string
+	// Tags holds every tag added via WithTag. Where a key was added more than once, the latest value wins.
+	Tags map[string]string
+	// Value is the amount counted, the duration in milliseconds or the gauge, histogram or distribution value.
+	Value float64
+	// Member is the value added to a set.
+	Member string
+}
+
+func (c Call) String() string {
+	parts := []string{fmt.Sprintf("%s %s=%v", c.Kind, c.Name, c.Value)}
+	if c.Kind == Set {
+		parts = []string{fmt.Sprintf("%s %s=%q", c.Kind, c.Name, c.Member)}
+	}
+	if c.Prefix != "" {
+		parts = append(parts, "prefix="+c.Prefix)
+	}
+	keys := make([]string, 0, len(c.Tags))
+	for key := range c.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts = append(parts, key+":"+c.Tags[key])
+	}
+	return strings.Join(parts, " ")
+}
+
+// Recorder is a metrics.MetricsRecorder recording every call. It is safe for concurrent use.
+// Recorders created from it via WithTag record to the same calls and share its prefix.
+type Recorder struct {
+	recorder *recorder
+	tags     map[string]string
+}
+
+type recorder struct {
+	mu     sync.Mutex
+	calls  []Call
+	prefix string
+}
+
+func New() *Recorder {
+	return &Recorder{recorder: &recorder{}, tags: map[string]string{}}
+}
+
+func (r *Recorder) IncrementCount(metricName string) {
+	r.record(Count, metricName, 1)
+}
+
+func (r *Recorder) IncrementCountBy(metricName string, amount int) {
+	r.record(Count, metricName, float64(amount))
+}
+
+func (r *Recorder) MeasureSince(metricName string, since time.Time) {
+	r.record(Timing, metricName, float64(time.Since(since))/float64(time.Millisecond))
+}
+
+func (r *Recorder) MeasureDurationMS(metricName string, durationMS float32) {
+	r.record(Timing, metricName, float64(durationMS))
+}
+
+func (r *Recorder) SetGauge(metricName string, val float32) {
+	r.record(Gauge, metricName, float64(val))
+}
+
+func (r *Recorder) Histogram(metricName string, value float32) {
+	r.record(Histogram, metricName, float64(value))
+}
+
+func (r *Recorder) Distribution(metricName string, value float32) {
+	r.record(Distribution, metricName, float64(value))
+}
+
+func (r *Recorder) AddToSet(metricName string, value string) {
+	r.recordCall(Call{Kind: Set, Name: metricName, Member: value})
+}
+
+func (r *Recorder) SetPrefix(prefix string) {
+	r.recorder.mu.Lock()
+	defer r.recorder.mu.Unlock()
+	r.recorder.prefix = prefix
+}
+
+func (r *Recorder) WithTag(key, value string) metrics.MetricsRecorder {
+	tags := make(map[string]string, len(r.tags)+1)
+	for k, v := range r.tags {
+		tags[k] = v
+	}
+	tags[key] = value
+	return &Recorder{recorder: r.recorder, tags: tags}
+}
+
+// Calls returns every recorded call, oldest first.
+func (r *Recorder) Calls() []Call {
+	return r.Filter(func(Call) bool { return true })
+}
+
+// Filter returns the recorded calls matching fn.
+func (r *Recorder) Filter(fn func(Call) bool) []Call {
+	r.recorder.mu.Lock()
+	defer r.recorder.mu.Unlock()
+	calls := []Call{}
+	for _, call := range r.recorder.calls {
+		if fn(call) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset discards the recorded calls.
+func (r *Recorder) Reset() {
+	r.recorder.mu.Lock()
+	defer r.recorder.mu.Unlock()
+	r.recorder.calls = nil
+}
+
+// CounterValue returns the total counted for a metric by calls with at least the given tags.
+func (r *Recorder) CounterValue(name string, tags map[string]string) int {
+	total := 0
+	for _, call := range r.Filter(matching(Count, name, tags)) {
+		total += int(call.Value)
+	}
+	return total
+}
+
+// TimingSamples returns the durations, in milliseconds, measured for a metric with any tags, oldest first.
+func (r *Recorder) TimingSamples(name string) []float64 {
+	return r.samples(Timing, name)
+}
+
+// HistogramSamples returns the values recorded in a histogram with any tags, oldest first.
+func (r *Recorder) HistogramSamples(name string) []float64 {
+	return r.samples(Histogram, name)
+}
+
+// DistributionSamples returns the values recorded in a distribution with any tags, oldest first.
+func (r *Recorder) DistributionSamples(name string) []float64 {
+	return r.samples(Distribution, name)
+}
+
+// GaugeLast returns the latest value set for a gauge with any tags, and whether it was set.
+func (r *Recorder) GaugeLast(name string) (float64, bool) {
+	calls := r.Filter(matching(Gauge, name, nil))
+	if len(calls) == 0 {
+		return 0, false
+	}
+	return calls[len(calls)-1].Value, true
+}
+
+// SetMembers returns the distinct values added to a set with any tags, in the order first added.
+func (r *Recorder) SetMembers(name string) []string {
+	members := []string{}
+	seen := map[string]bool{}
+	for _, call := range r.Filter(matching(Set, name, nil)) {
+		if !seen[call.Member] {
+			seen[call.Member] = true
+			members = append(members, call.Member)
+		}
+	}
+	return members
+}
+
+func (r *Recorder) samples(kind, name string) []float64 {
+	samples := []float64{}
+	for _, call := range r.Filter(matching(kind, name, nil)) {
+		samples = append(samples, call.Value)
+	}
+	return samples
+}
+
+func (r *Recorder) record(kind, name string, value float64) {
+	r.recordCall(Call{Kind: kind, Name: name, Value: value})
+}
+
+func (r *Recorder) recordCall(call Call) {
+	call.Tags = make(map[string]string, len(r.tags))
+	for k, v := range r.tags {
+		call.Tags[k] = v
+	}
+
+	r.recorder.mu.Lock()
+	defer r.recorder.mu.Unlock()
+	call.Prefix = r.recorder.prefix
+	r.recorder.calls = append(r.recorder.calls, call)
+}
+
+func matching(kind, name string, tags map[string]string) func(Call) bool {
+	return func(c Call) bool {
+		if c.Kind != kind || c.Name != name {
+			return false
+		}
+		for key, value := range tags {
+			if have, ok := c.Tags[key]; !ok || have != value {
+				return false
+			}
+		}
+		return true
+	}
+}
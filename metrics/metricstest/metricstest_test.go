@@ -0,0 +1,119 @@
+package metricstest_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/intercom/gocore/metrics"
+	"github.com/intercom/gocore/metrics/metricstest"
+)
+
+func TestCounterValueMatchesTags(t *testing.T) {
+	recorder := metricstest.New()
+	recorder.IncrementCount("requests")
+	recorder.WithTag("status", "200").IncrementCountBy("requests", 2)
+	recorder.WithTag("status", "500").WithTag("route", "/users").IncrementCount("requests")
+	recorder.IncrementCount("other")
+
+	if want, have := 4, recorder.CounterValue("requests", nil); want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := 2, recorder.CounterValue("requests", map[string]string{"status": "200"}); want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := 1, recorder.CounterValue("requests", map[string]string{"route": "/users"}); want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := 0, recorder.CounterValue("requests", map[string]string{"status": "404"}); want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
+
+func TestTimingSamplesAndGaugeLast(t *testing.T) {
+	recorder := metricstest.New()
+	recorder.MeasureDurationMS("latency", 12)
+	recorder.WithTag("route", "/users").MeasureDurationMS("latency", 30)
+	recorder.SetGauge("depth", 3)
+	recorder.SetGauge("depth", 5)
+
+	samples := recorder.TimingSamples("latency")
+	if len(samples) != 2 || samples[0] != 12 || samples[1] != 30 {
+		t.Errorf("want [12 30], have %v", samples)
+	}
+	if value, ok := recorder.GaugeLast("depth"); !ok || value != 5 {
+		t.Errorf("want 5, have %v (set %v)", value, ok)
+	}
+	if _, ok := recorder.GaugeLast("missing"); ok {
+		t.Error("want missing gauge unset")
+	}
+}
+
+func TestCallsRecordTagsAndPrefix(t *testing.T) {
+	recorder := metricstest.New()
+	tagged := recorder.WithTag("a", "1").WithTag("a", "2")
+	recorder.SetPrefix("api")
+	tagged.IncrementCount("requests")
+
+	calls := recorder.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("want 1 call, have %v", calls)
+	}
+	if want, have := "count requests=1 prefix=api a:2", calls[0].String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	recorder.Reset()
+	if calls := recorder.Calls(); len(calls) != 0 {
+		t.Errorf("want no calls after reset, have %v", calls)
+	}
+}
+
+func TestRecorderIsConcurrencySafe(t *testing.T) {
+	var recorder metrics.MetricsRecorder = metricstest.New()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				recorder.WithTag("worker", "w").IncrementCount("jobs")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if want, have := 1000, recorder.(*metricstest.Recorder).CounterValue("jobs", nil); want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
+
+func TestHistogramAndDistributionSamples(t *testing.T) {
+	recorder := metricstest.New()
+	recorder.Histogram("payload.bytes", 512)
+	recorder.WithTag("route", "/users").Histogram("payload.bytes", 1024)
+	recorder.Distribution("batch.size", 20)
+
+	if samples := recorder.HistogramSamples("payload.bytes"); len(samples) != 2 || samples[0] != 512 || samples[1] != 1024 {
+		t.Errorf("want [512 1024], have %v", samples)
+	}
+	if samples := recorder.DistributionSamples("batch.size"); len(samples) != 1 || samples[0] != 20 {
+		t.Errorf("want [20], have %v", samples)
+	}
+	if samples := recorder.HistogramSamples("batch.size"); len(samples) != 0 {
+		t.Errorf("want no histogram samples for a distribution, have %v", samples)
+	}
+}
+
+func TestSetMembers(t *testing.T) {
+	recorder := metricstest.New()
+	recorder.AddToSet("users", "alice")
+	recorder.WithTag("app", "web").AddToSet("users", "bob")
+	recorder.AddToSet("users", "alice")
+
+	if members := recorder.SetMembers("users"); len(members) != 2 || members[0] != "alice" || members[1] != "bob" {
+		t.Errorf("want [alice bob], have %v", members)
+	}
+	if want, have := `set users="alice"`, recorder.Calls()[0].String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
@@ -11,5 +11,8 @@ func (*NoopRecorder) IncrementCountBy(string, int)                {}
 func (*NoopRecorder) MeasureSince(string, time.Time)              {}
 func (*NoopRecorder) MeasureDurationMS(string, float32)           {}
 func (*NoopRecorder) SetGauge(string, float32)                    {}
+func (*NoopRecorder) Histogram(string, float32)                   {}
+func (*NoopRecorder) Distribution(string, float32)                {}
+func (*NoopRecorder) AddToSet(string, string)                     {}
 func (*NoopRecorder) SetPrefix(string)                            {}
 func (n *NoopRecorder) WithTag(key, value string) MetricsRecorder { return n }
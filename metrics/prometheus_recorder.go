@@ -0,0 +1,372 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	stdlog "log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPrometheusBuckets are the upper bounds, in seconds, of the histogram buckets for measured durations.
+var DefaultPrometheusBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultPrometheusValueBuckets are the upper bounds of the histogram buckets for Histogram and Distribution values.
+var DefaultPrometheusValueBuckets = []float64{1, 5, 10, 50, 100, 500, 1000, 5000, 10000, 50000, 100000}
+
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// A MetricsRecorder implementation keeping metrics in memory to be scraped by Prometheus.
+// Counts are counters with a "_total" suffix, gauges are gauges and durations are histograms in seconds
+// with a "_seconds" suffix. Histogram and Distribution values are both histograms, as Prometheus computes
+// percentiles across instances from histograms. Tags are labels, which may differ between calls for the same metric.
+// Names are joined with underscores: namespace_prefix_name, with characters Prometheus doesn't allow replaced.
+// Calls Prometheus can't represent, such as a name used for two types of metric, a reserved label name or
+// decreasing a counter, are ignored and reported to the error handler.
+type PrometheusRecorder struct {
+	*prometheusMetrics
+	labels []prometheusLabel
+}
+
+// prometheusMetrics is the state shared by a PrometheusRecorder and those derived from it by WithTag.
+type prometheusMetrics struct {
+	namespace string
+
+	mu           sync.Mutex
+	prefix       string
+	buckets      []float64
+	bucketsFor   map[string][]float64
+	families     map[string]*prometheusFamily
+	errorHandler func(error)
+	reported     map[string]bool // problems already reported, to report each once
+}
+
+type prometheusFamily struct {
+	typ     string
+	buckets []float64
+	series  map[string]*prometheusSeries
+}
+
+type prometheusSeries struct {
+	labels string
+	value  float64 // counter or gauge value, or histogram sum
+	counts []uint64
+	count  uint64
+}
+
+type prometheusLabel struct {
+	name  string
+	value string
+}
+
+// NewPrometheusRecorder returns a PrometheusRecorder naming metrics with namespace and observing durations
+// into buckets, or DefaultPrometheusBuckets if none are given.
+func NewPrometheusRecorder(namespace string, buckets ...float64) *PrometheusRecorder {
+	if len(buckets) == 0 {
+		buckets = DefaultPrometheusBuckets
+	}
+	return &PrometheusRecorder{
+		prometheusMetrics: &prometheusMetrics{
+			namespace:  namespace,
+			buckets:    sortedBuckets(buckets),
+			bucketsFor: map[string][]float64{},
+			families:   map[string]*prometheusFamily{},
+			reported:   map[string]bool{},
+			errorHandler: func(err error) {
+				stdlog.Printf("[ERR] %v", err)
+			},
+		},
+	}
+}
+
+// SetErrorHandler sets the function ignored calls are reported to, once each, in place of the standard logger.
+func (p *PrometheusRecorder) SetErrorHandler(handler func(error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errorHandler = handler
+}
+
+func (p *PrometheusRecorder) IncrementCount(metricName string) {
+	p.IncrementCountBy(metricName, 1)
+}
+
+// IncrementCountBy ignores negative amounts, as Prometheus counters only go up.
+func (p *PrometheusRecorder) IncrementCountBy(metricName string, amount int) {
+	p.mu.Lock()
+	name := p.nameLocked(metricName, "_total")
+	var err error
+	if amount < 0 {
+		err = p.problemLocked("negative:"+name, "counter %s can't be decremented, ignoring an increment of %d", name, amount)
+	} else if series, conflict := p.seriesLocked(name, "counter", p.labels); series != nil {
+		series.value += float64(amount)
+	} else {
+		err = conflict
+	}
+	p.mu.Unlock()
+	p.report(err)
+}
+
+func (p *PrometheusRecorder) MeasureSince(metricName string, since time.Time) {
+	p.observe(metricName, "_seconds", time.Since(since).Seconds())
+}
+
+func (p *PrometheusRecorder) MeasureDurationMS(metricName string, durationMS float32) {
+	p.observe(metricName, "_seconds", float32To64(durationMS)/1000)
+}
+
+func (p *PrometheusRecorder) Histogram(metricName string, value float32) {
+	p.observe(metricName, "", float32To64(value))
+}
+
+func (p *PrometheusRecorder) Distribution(metricName string, value float32) {
+	p.observe(metricName, "", float32To64(value))
+}
+
+func (p *PrometheusRecorder) SetGauge(metricName string, val float32) {
+	p.mu.Lock()
+	series, err := p.seriesLocked(p.nameLocked(metricName, ""), "gauge", p.labels)
+	if series != nil {
+		series.value = float32To64(val)
+	}
+	p.mu.Unlock()
+	p.report(err)
+}
+
+// AddToSet does nothing, as Prometheus has no set type and counting unique values would mean keeping them all.
+func (p *PrometheusRecorder) AddToSet(metricName string, value string) {}
+
+// SetPrefix sets a prefix for metric names, for this recorder and those derived from it by WithTag.
+func (p *PrometheusRecorder) SetPrefix(prefix string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prefix = prefix
+}
+
+// SetBuckets sets the histogram buckets for a metric not yet measured, in seconds for durations.
+func (p *PrometheusRecorder) SetBuckets(metricName string, buckets ...float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bucketsFor[metricName] = sortedBuckets(buckets)
+}
+
+// WithTag returns a new PrometheusRecorder recording to the same metrics with the label added.
+// Label names reserved by Prometheus, "le" and those starting with "__", are ignored.
+func (p *PrometheusRecorder) WithTag(key, value string) MetricsRecorder {
+	name := prometheusName(key)
+	if name == "le" || strings.HasPrefix(name, "__") {
+		p.mu.Lock()
+		err := p.problemLocked("label:"+name, "label name %s is reserved by Prometheus, ignoring it", name)
+		p.mu.Unlock()
+		p.report(err)
+		return &PrometheusRecorder{prometheusMetrics: p.prometheusMetrics, labels: p.labels}
+	}
+	labels := make([]prometheusLabel, 0, len(p.labels)+1)
+	for _, label := range p.labels {
+		if label.name != name {
+			labels = append(labels, label)
+		}
+	}
+	labels = append(labels, prometheusLabel{name: name, value: value})
+	sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+	return &PrometheusRecorder{prometheusMetrics: p.prometheusMetrics, labels: labels}
+}
+
+// Handler returns an http.Handler serving the metrics in the Prometheus text format, to mount at /metrics.
+func (p *PrometheusRecorder) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", prometheusContentType)
+		w.Write(p.exposition())
+	})
+}
+
+func (p *PrometheusRecorder) observe(metricName, suffix string, value float64) {
+	p.mu.Lock()
+	err := p.observeLocked(metricName, suffix, value)
+	p.mu.Unlock()
+	p.report(err)
+}
+
+func (p *PrometheusRecorder) observeLocked(metricName, suffix string, value float64) error {
+	name := p.nameLocked(metricName, suffix)
+	if _, ok := p.families[name]; !ok {
+		buckets, ok := p.bucketsFor[metricName]
+		if !ok {
+			buckets = p.buckets
+			if suffix == "" {
+				buckets = DefaultPrometheusValueBuckets
+			}
+		}
+		p.families[name] = &prometheusFamily{typ: "histogram", buckets: buckets, series: map[string]*prometheusSeries{}}
+	}
+	series, err := p.seriesLocked(name, "histogram", p.labels)
+	if series == nil {
+		return err
+	}
+	for i, bound := range p.families[name].buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+	series.count++
+	series.value += value
+	return nil
+}
+
+// seriesLocked returns the series for the labels in a family, creating them as needed, or nil if the name
+// is already used by a metric of another type, with an error the first time that happens.
+func (m *prometheusMetrics) seriesLocked(name, typ string, labels []prometheusLabel) (*prometheusSeries, error) {
+	family, ok := m.families[name]
+	if !ok {
+		family = &prometheusFamily{typ: typ, series: map[string]*prometheusSeries{}}
+		m.families[name] = family
+	}
+	if family.typ != typ {
+		return nil, m.problemLocked("type:"+name+":"+typ, "metric %s is a %s, ignoring it as a %s", name, family.typ, typ)
+	}
+	key := formatPrometheusLabels(labels)
+	series, ok := family.series[key]
+	if !ok {
+		series = &prometheusSeries{labels: key, counts: make([]uint64, len(family.buckets))}
+		family.series[key] = series
+	}
+	return series, nil
+}
+
+// problemLocked returns an error for a problem, unless one was already returned for key.
+func (m *prometheusMetrics) problemLocked(key, format string, args ...interface{}) error {
+	if m.reported[key] {
+		return nil
+	}
+	m.reported[key] = true
+	return fmt.Errorf("prometheus recorder: "+format, args...)
+}
+
+// report passes err, if any, to the error handler.
+func (m *prometheusMetrics) report(err error) {
+	if err == nil {
+		return
+	}
+	m.mu.Lock()
+	handler := m.errorHandler
+	m.mu.Unlock()
+	if handler != nil {
+		handler(err)
+	}
+}
+
+func (m *prometheusMetrics) nameLocked(metricName, suffix string) string {
+	parts := []string{}
+	for _, part := range []string{m.namespace, m.prefix, metricName} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	name := prometheusName(strings.Join(parts, "_"))
+	if !strings.HasSuffix(name, suffix) {
+		name += suffix
+	}
+	return name
+}
+
+// exposition returns the metrics in the Prometheus text format, sorted by name and labels.
+func (m *prometheusMetrics) exposition() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := bytes.Buffer{}
+	for _, name := range names {
+		family := m.families[name]
+		if len(family.series) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(family.series))
+		for key := range family.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", name, family.typ)
+		for _, key := range keys {
+			series := family.series[key]
+			if family.typ != "histogram" {
+				fmt.Fprintf(&buf, "%s%s %s\n", name, braced(series.labels), formatPrometheusValue(series.value))
+				continue
+			}
+			for i, bound := range family.buckets {
+				fmt.Fprintf(&buf, "%s_bucket%s %d\n", name, braced(withLe(series.labels, formatPrometheusValue(bound))), series.counts[i])
+			}
+			fmt.Fprintf(&buf, "%s_bucket%s %d\n", name, braced(withLe(series.labels, "+Inf")), series.count)
+			fmt.Fprintf(&buf, "%s_sum%s %s\n", name, braced(series.labels), formatPrometheusValue(series.value))
+			fmt.Fprintf(&buf, "%s_count%s %d\n", name, braced(series.labels), series.count)
+		}
+	}
+	return buf.Bytes()
+}
+
+// prometheusName replaces characters not allowed in metric and label names with underscores.
+func prometheusName(name string) string {
+	mapped := []rune(name)
+	for i, r := range mapped {
+		valid := r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')
+		if !valid {
+			mapped[i] = '_'
+		}
+	}
+	return string(mapped)
+}
+
+var prometheusLabelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatPrometheusLabels returns labels as they appear between braces, e.g. `method="get",status="200"`.
+func formatPrometheusLabels(labels []prometheusLabel) string {
+	formatted := make([]string, len(labels))
+	for i, label := range labels {
+		formatted[i] = label.name + `="` + prometheusLabelValueEscaper.Replace(label.value) + `"`
+	}
+	return strings.Join(formatted, ",")
+}
+
+func withLe(labels, le string) string {
+	if labels == "" {
+		return `le="` + le + `"`
+	}
+	return labels + `,le="` + le + `"`
+}
+
+func braced(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+func formatPrometheusValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedBuckets(buckets []float64) []float64 {
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// float32To64 converts a float32 to the float64 with the same shortest decimal representation,
+// so 0.1 is 0.1 rather than 0.10000000149011612.
+func float32To64(v float32) float64 {
+	converted, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+	return converted
+}
@@ -0,0 +1,144 @@
+package metrics_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/intercom/gocore/metrics"
+)
+
+func TestPrometheusRecorderExposition(t *testing.T) {
+	recorder := metrics.NewPrometheusRecorder("billing", 0.1, 1)
+	recorder.IncrementCount("charges")
+	recorder.WithTag("status", "declined").IncrementCountBy("charges", 2)
+	recorder.WithTag("status", `say "hi"`).IncrementCount("charges")
+	recorder.SetGauge("queue.depth", 0.1)
+	recorder.MeasureDurationMS("charge.latency", 50)
+	recorder.WithTag("card", "visa").MeasureDurationMS("charge.latency", 500)
+	recorder.MeasureDurationMS("charge.latency", 5000)
+
+	want := `# TYPE billing_charge_latency_seconds histogram
+billing_charge_latency_seconds_bucket{le="0.1"} 1
+billing_charge_latency_seconds_bucket{le="1"} 1
+billing_charge_latency_seconds_bucket{le="+Inf"} 2
+billing_charge_latency_seconds_sum 5.05
+billing_charge_latency_seconds_count 2
+billing_charge_latency_seconds_bucket{card="visa",le="0.1"} 0
+billing_charge_latency_seconds_bucket{card="visa",le="1"} 1
+billing_charge_latency_seconds_bucket{card="visa",le="+Inf"} 1
+billing_charge_latency_seconds_sum{card="visa"} 0.5
+billing_charge_latency_seconds_count{card="visa"} 1
+# TYPE billing_charges_total counter
+billing_charges_total 1
+billing_charges_total{status="declined"} 2
+billing_charges_total{status="say \"hi\""} 1
+# TYPE billing_queue_depth gauge
+billing_queue_depth 0.1
+`
+	checkExposition(t, want, recorder)
+}
+
+func TestPrometheusRecorderPrefixAndLabels(t *testing.T) {
+	recorder := metrics.NewPrometheusRecorder("")
+	recorder.SetErrorHandler(func(error) {})
+	recorder.SetPrefix("api")
+	tagged := recorder.WithTag("method", "get").WithTag("route", "/users").WithTag("method", "post")
+	tagged.IncrementCount("requests")
+	recorder.SetGauge("requests_total", 1) // already a counter, so ignored
+
+	want := `# TYPE api_requests_total counter
+api_requests_total{method="post",route="/users"} 1
+`
+	checkExposition(t, want, recorder)
+}
+
+func TestPrometheusRecorderSetBuckets(t *testing.T) {
+	recorder := metrics.NewPrometheusRecorder("")
+	recorder.SetBuckets("batch", 60)
+	recorder.MeasureDurationMS("batch", 30000)
+
+	want := `# TYPE batch_seconds histogram
+batch_seconds_bucket{le="60"} 1
+batch_seconds_bucket{le="+Inf"} 1
+batch_seconds_sum 30
+batch_seconds_count 1
+`
+	checkExposition(t, want, recorder)
+}
+
+func TestPrometheusRecorderReportsIgnoredCalls(t *testing.T) {
+	recorder := metrics.NewPrometheusRecorder("")
+	var reported []string
+	recorder.SetErrorHandler(func(err error) { reported = append(reported, err.Error()) })
+
+	recorder.WithTag("le", "1").WithTag("__name__", "x").Histogram("size", 5)
+	recorder.IncrementCountBy("hits", -1)
+	recorder.IncrementCountBy("hits", -1)
+	recorder.SetGauge("size", 1)
+
+	want := `# TYPE size histogram
+size_bucket{le="1"} 0
+size_bucket{le="5"} 1
+size_bucket{le="10"} 1
+size_bucket{le="50"} 1
+size_bucket{le="100"} 1
+size_bucket{le="500"} 1
+size_bucket{le="1000"} 1
+size_bucket{le="5000"} 1
+size_bucket{le="10000"} 1
+size_bucket{le="50000"} 1
+size_bucket{le="100000"} 1
+size_bucket{le="+Inf"} 1
+size_sum 5
+size_count 1
+`
+	checkExposition(t, want, recorder)
+	wantReported := []string{
+		"prometheus recorder: label name le is reserved by Prometheus, ignoring it",
+		"prometheus recorder: label name __name__ is reserved by Prometheus, ignoring it",
+		"prometheus recorder: counter hits_total can't be decremented, ignoring an increment of -1",
+		"prometheus recorder: metric size is a histogram, ignoring it as a gauge",
+	}
+	if want, have := strings.Join(wantReported, "\n"), strings.Join(reported, "\n"); want != have {
+		t.Errorf("want reported\n%s\nhave\n%s", want, have)
+	}
+}
+
+func checkExposition(t *testing.T, want string, recorder *metrics.PrometheusRecorder) {
+	w := httptest.NewRecorder()
+	recorder.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if want, have := "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"); want != have {
+		t.Errorf("want content type %s, have %s", want, have)
+	}
+	if have := w.Body.String(); want != have {
+		t.Errorf("want\n%s\nhave\n%s", want, have)
+	}
+}
+
+func TestPrometheusRecorderHistogramAndDistribution(t *testing.T) {
+	recorder := metrics.NewPrometheusRecorder("")
+	recorder.SetBuckets("payload.bytes", 100, 1000)
+	recorder.Histogram("payload.bytes", 512)
+	recorder.Distribution("payload.bytes", 2048)
+
+	want := `# TYPE payload_bytes histogram
+payload_bytes_bucket{le="100"} 0
+payload_bytes_bucket{le="1000"} 1
+payload_bytes_bucket{le="+Inf"} 2
+payload_bytes_sum 2560
+payload_bytes_count 2
+`
+	checkExposition(t, want, recorder)
+}
+
+func TestPrometheusRecorderIgnoresSets(t *testing.T) {
+	recorder := metrics.NewPrometheusRecorder("")
+	recorder.AddToSet("users", "alice")
+	recorder.IncrementCount("hits")
+
+	want := `# TYPE hits_total counter
+hits_total 1
+`
+	checkExposition(t, want, recorder)
+}
@@ -2,20 +2,48 @@ package metrics
 
 import (
 	"errors"
+	"net"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/armon/go-metrics"
 )
 
+// TagScheme is how a StatsdRecorder encodes tags, which plain statsd has no support for, into metric names.
+type TagScheme int
+
+const (
+	// GraphiteTags appends each tag as a name component: name.key_value.
+	GraphiteTags TagScheme = iota
+	// InfluxTags uses the InfluxDB/Telegraf statsd extension: name,key=value.
+	InfluxTags
+	// LibratoTags uses the Librato statsd extension: name#key=value.
+	LibratoTags
+)
+
 // A MetricsRecorder implementation which is just a wrapper around
 // the go-metrics library, to record to Statsd.
 type StatsdRecorder struct {
 	*metrics.Metrics
 	prefix string
+	scheme TagScheme
+	tags   []metrics.Label
+	sink   *metrics.StatsdSink
+	// sets is where sets are sent, as go-metrics has no set type.
+	sets *statsdSetConn
+	// root is the recorder this one was created from via WithTag, which holds the shared prefix.
+	root *StatsdRecorder
 }
 
 // Takes a host:port string of the statsite endpoint to write to.
+// Tags are encoded into metric names with GraphiteTags.
 func NewStatsdRecorder(statsiteEndpoint, namespace string) (*StatsdRecorder, error) {
+	return NewStatsdRecorderWithTagScheme(statsiteEndpoint, namespace, GraphiteTags)
+}
+
+// NewStatsdRecorderWithTagScheme is NewStatsdRecorder with tags encoded into metric names with scheme.
+func NewStatsdRecorderWithTagScheme(statsiteEndpoint, namespace string, scheme TagScheme) (*StatsdRecorder, error) {
 	if statsiteEndpoint == "" {
 		return nil, errors.New("Uninitialized StatsdRecorder")
 	}
@@ -25,7 +53,55 @@ func NewStatsdRecorder(statsiteEndpoint, namespace string) (*StatsdRecorder, err
 	config := metrics.DefaultConfig(namespace)
 	config.EnableHostname = false
 	m, _ := metrics.New(config, sink)
-	return &StatsdRecorder{Metrics: m}, nil
+	return &StatsdRecorder{Metrics: m, scheme: scheme, sink: sink, sets: &statsdSetConn{addr: statsiteEndpoint}}, nil
+}
+
+// statsdSetRedialInterval is how long sets are dropped for after failing to connect, as the statsd sink does.
+const statsdSetRedialInterval = 5 * time.Second
+
+// statsdSetConn is the connection sets are sent on, dialled on the first write and redialled after failures.
+type statsdSetConn struct {
+	addr string
+
+	mu       sync.Mutex
+	conn     net.Conn
+	redialAt time.Time
+	closed   bool
+}
+
+func (c *statsdSetConn) write(line string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	if c.conn == nil {
+		if time.Now().Before(c.redialAt) {
+			return
+		}
+		conn, err := net.Dial("udp", c.addr)
+		if err != nil {
+			c.redialAt = time.Now().Add(statsdSetRedialInterval)
+			return
+		}
+		c.conn = conn
+	}
+	if _, err := c.conn.Write([]byte(line)); err != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+func (c *statsdSetConn) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
 }
 
 func (m *StatsdRecorder) IncrementCount(metricName string) {
@@ -48,15 +124,109 @@ func (m *StatsdRecorder) SetGauge(metricName string, val float32) {
 	m.Metrics.SetGauge(m.prefixedMetricName(metricName), val)
 }
 
-func (m *StatsdRecorder) WithTag(key, value string) MetricsRecorder { return m }
+// Histogram is sent as a timer, as plain statsd has no histogram type.
+func (m *StatsdRecorder) Histogram(metricName string, value float32) {
+	m.Metrics.AddSample(m.prefixedMetricName(metricName), value)
+}
+
+// Distribution is sent as a timer, as plain statsd has no distribution type.
+func (m *StatsdRecorder) Distribution(metricName string, value float32) {
+	m.Metrics.AddSample(m.prefixedMetricName(metricName), value)
+}
+
+// AddToSet sends a statsd set, counting unique values per flush interval.
+// Values are sent as they are, other than any ':', '|' or newline replaced with underscores.
+func (m *StatsdRecorder) AddToSet(metricName string, value string) {
+	if m.sets == nil {
+		return
+	}
+	// flattened as the go-metrics statsd sink does
+	name := escapeTag(strings.Join(m.setKey(metricName), "."), ": ")
+	m.sets.write(name + ":" + escapeTag(value, ":|\n") + "|s\n")
+}
+
+// setKey returns the key for a set, built as go-metrics builds the keys of the other metric types.
+func (m *StatsdRecorder) setKey(metricName string) []string {
+	key := m.prefixedMetricName(metricName)
+	if m.EnableTypePrefix {
+		key = append([]string{"set"}, key...)
+	}
+	if m.ServiceName != "" && !m.EnableServiceLabel {
+		key = append([]string{m.ServiceName}, key...)
+	}
+	return key
+}
+
+// WithTag returns a new StatsdRecorder that has the tag added to it.
+// It shares the prefix with this recorder, so SetPrefix on either applies to both.
+func (m *StatsdRecorder) WithTag(key, value string) MetricsRecorder {
+	newRecorder := *m
+	newRecorder.root = m.rootRecorder()
+	newRecorder.tags = append(append([]metrics.Label{}, m.tags...), metrics.Label{Name: key, Value: value})
+	return &newRecorder
+}
+
+// Close stops sending metrics and closes the connections, for this recorder and those created from it via WithTag.
+// Metrics not yet flushed are dropped. Call it once, on shutdown, after the last metric is recorded.
+func (m *StatsdRecorder) Close() error {
+	if m.sink != nil {
+		m.sink.Shutdown()
+	}
+	if m.sets == nil {
+		return nil
+	}
+	return m.sets.close()
+}
 
 func (m *StatsdRecorder) SetPrefix(prefix string) {
-	m.prefix = prefix
+	m.rootRecorder().prefix = prefix
 }
 
+func (m *StatsdRecorder) rootRecorder() *StatsdRecorder {
+	if m.root != nil {
+		return m.root
+	}
+	return m
+}
+
+// prefixedMetricName returns the key for a metric, with the prefix and any tags.
 func (m *StatsdRecorder) prefixedMetricName(metricName string) []string {
-	if m.prefix == "" {
-		return []string{metricName}
+	key := []string{metricName}
+	if prefix := m.rootRecorder().prefix; prefix != "" {
+		key = []string{prefix, metricName}
+	}
+	if len(m.tags) == 0 {
+		return key
+	}
+
+	last := len(key) - 1
+	switch m.scheme {
+	case InfluxTags:
+		key[last] += "," + joinTags(m.tags, ",", ",= ")
+	case LibratoTags:
+		key[last] += "#" + joinTags(m.tags, ",", ",=# ")
+	default:
+		for _, tag := range m.tags {
+			key = append(key, escapeTag(tag.Name, ". ")+"_"+escapeTag(tag.Value, ". "))
+		}
 	}
-	return []string{m.prefix, metricName}
+	return key
+}
+
+// joinTags formats tags as key=value, separated by sep, replacing any of reserved in them with underscores.
+func joinTags(tags []metrics.Label, sep, reserved string) string {
+	formatted := make([]string, len(tags))
+	for i, tag := range tags {
+		formatted[i] = escapeTag(tag.Name, reserved) + "=" + escapeTag(tag.Value, reserved)
+	}
+	return strings.Join(formatted, sep)
+}
+
+func escapeTag(s, reserved string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(reserved, r) {
+			return '_'
+		}
+		return r
+	}, s)
 }
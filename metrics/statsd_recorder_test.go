@@ -0,0 +1,152 @@
+package metrics_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intercom/gocore/metrics"
+)
+
+func TestStatsdRecorderEncodesTags(t *testing.T) {
+	tests := []struct {
+		scheme metrics.TagScheme
+		want   string
+	}{
+		{metrics.GraphiteTags, "namespace.api.hits.status_200.route_users_v1:1.000000|c\n"},
+		{metrics.InfluxTags, "namespace.api.hits,status=200,route=users.v1:1.000000|c\n"},
+		{metrics.LibratoTags, "namespace.api.hits#status=200,route=users.v1:1.000000|c\n"},
+	}
+	for _, test := range tests {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder, _ := metrics.NewStatsdRecorderWithTagScheme(conn.LocalAddr().String(), "namespace", test.scheme)
+		recorder.SetPrefix("api")
+		recorder.WithTag("status", "200").WithTag("route", "users.v1").IncrementCount("hits")
+
+		if have := readStatsd(t, conn); !strings.Contains(have, test.want) {
+			t.Errorf("want %q in %q", test.want, have)
+		}
+		conn.Close()
+	}
+}
+
+func TestStatsdRecorderTagsMakeNewInstance(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	recorder, _ := metrics.NewStatsdRecorder(conn.LocalAddr().String(), "namespace")
+	recorder.WithTag("status", "200")
+	recorder.SetGauge("depth", 2)
+
+	if want, have := "namespace.depth:2.000000|g\n", readStatsd(t, conn); !strings.Contains(have, want) {
+		t.Errorf("want %q in %q", want, have)
+	}
+}
+
+func TestStatsdRecorderTagsSharePrefix(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	recorder, _ := metrics.NewStatsdRecorder(conn.LocalAddr().String(), "namespace")
+	tagged := recorder.WithTag("status", "200")
+	recorder.SetPrefix("api")
+	tagged.SetGauge("depth", 2)
+
+	if want, have := "namespace.api.depth.status_200:2.000000|g\n", readStatsd(t, conn); !strings.Contains(have, want) {
+		t.Errorf("want %q in %q", want, have)
+	}
+}
+
+func TestStatsdRecorderSendsHistogramsAsTimers(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	recorder, _ := metrics.NewStatsdRecorder(conn.LocalAddr().String(), "namespace")
+	recorder.Histogram("payload", 512)
+	recorder.Distribution("batch", 20)
+
+	have := readStatsd(t, conn)
+	for _, want := range []string{"namespace.payload:512.000000|ms\n", "namespace.batch:20.000000|ms\n"} {
+		if !strings.Contains(have, want) {
+			t.Errorf("want %q in %q", want, have)
+		}
+	}
+}
+
+func TestStatsdRecorderAddToSet(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	recorder, _ := metrics.NewStatsdRecorder(conn.LocalAddr().String(), "namespace")
+	recorder.SetPrefix("api")
+	recorder.WithTag("app", "web").AddToSet("users", "ali|ce")
+
+	if want, have := "namespace.api.users.app_web:ali_ce|s\n", readStatsd(t, conn); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestStatsdRecorderAddToSetWithTypePrefix(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	recorder, _ := metrics.NewStatsdRecorderWithTagScheme(conn.LocalAddr().String(), "namespace", metrics.InfluxTags)
+	recorder.EnableTypePrefix = true
+	recorder.WithTag("app", "web").AddToSet("users", "alice")
+
+	if want, have := "namespace.set.users,app=web:alice|s\n", readStatsd(t, conn); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestStatsdRecorderConnectsOnFirstSet(t *testing.T) {
+	recorder, err := metrics.NewStatsdRecorder("no-port", "namespace")
+	if err != nil {
+		t.Fatalf("want no error until sending, have %v", err)
+	}
+	recorder.AddToSet("users", "alice") // dropped
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatsdRecorderClose(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	recorder, _ := metrics.NewStatsdRecorder(conn.LocalAddr().String(), "namespace")
+	recorder.AddToSet("users", "alice")
+	if want, have := "namespace.users:alice|s\n", readStatsd(t, conn); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readStatsd(t *testing.T, conn net.PacketConn) string {
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
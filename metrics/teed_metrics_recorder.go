@@ -41,6 +41,24 @@ func (t *TeedMetricsRecorder) SetGauge(metricName string, val float32) {
 	}
 }
 
+func (t *TeedMetricsRecorder) Histogram(metricName string, value float32) {
+	for _, m := range t.metrics {
+		m.Histogram(metricName, value)
+	}
+}
+
+func (t *TeedMetricsRecorder) Distribution(metricName string, value float32) {
+	for _, m := range t.metrics {
+		m.Distribution(metricName, value)
+	}
+}
+
+func (t *TeedMetricsRecorder) AddToSet(metricName string, value string) {
+	for _, m := range t.metrics {
+		m.AddToSet(metricName, value)
+	}
+}
+
 func (t *TeedMetricsRecorder) SetPrefix(prefix string) {
 	for _, m := range t.metrics {
 		m.SetPrefix(prefix)